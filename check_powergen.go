@@ -2,200 +2,408 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
-	"net"
-	"os"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aretaja/godevman"
-	"github.com/aretaja/icingahelper"
 	"github.com/kr/pretty"
 )
 
-// Adds syncro check functionality to checkParams type
+func init() {
+	registerCheck(&checkPowerGen{})
+}
+
+// Adds power generator check functionality to checkParams type
 type checkPowerGen struct {
-	subParams struct{ ctype, wVolt, cVolt, wCur, cCur, wPow, cPow, wFreq, cFreq, wBat, cBat, wFuel, cFuel, wTemp, cTemp string }
+	subParams struct {
+		ctype                                                                                      string
+		wVolt, cVolt, wCur, cCur, wPow, cPow, wFreq, cFreq, wBat, cBat, wFuel, cFuel, wTemp, cTemp threshold
+		wVoltUnb, cVoltUnb, wCurUnb, cCurUnb                                                       threshold
+		minCurUnb                                                                                  float64
+		wMainsVolt, cMainsVolt, wGenVolt, cGenVolt                                                 phaseThreshold
+		phases                                                                                     phaseCount
+		modePolicy, breakerPolicy, enginePolicy                                                    statePolicy
+		policy                                                                                     policyFile
+		powerPolicy                                                                                statePolicy
+		wAut, cAut                                                                                 threshold
+		tank                                                                                       float64
+		fuelWindow                                                                                 time.Duration
+		wService, cService, wServiceDays, cServiceDays                                             threshold
+		service                                                                                    serviceInfo
+		serviceFile                                                                                serviceFile
+		detectStarts                                                                               bool
+		eventHold                                                                                  time.Duration
+		exercise                                                                                   exerciseWindows
+		detectCycling                                                                              bool
+		cycleWindow                                                                                time.Duration
+		cycleStarts                                                                                uint64
+		wRunTime, cRunTime                                                                         threshold
+		detectFuel                                                                                 bool
+		refuelMin                                                                                  float64
+		wFuelDrop, cFuelDrop                                                                       threshold
+		frozen                                                                                     time.Duration
+		frozenState                                                                                frozenState
+		ratedKW, ratedA                                                                            float64
+		wPowLoad, cPowLoad, wCurLoad, cCurLoad                                                     threshold
+	}
+	situation string   // power situation of site. Empty if not evaluated
+	state     genState // persistent state of host
 	checkParams
 }
 
-func (c *checkPowerGen) state() {
-	c.initSubParams()
+func (c *checkPowerGen) Name() string {
+	return "power_gen"
+}
+
+func (c *checkPowerGen) Info() []string {
+	return []string{"Power generator state checks.",
+		"\tAlarms are based on provided or default arguments.",
+		"\tThresholds use Nagios range syntax [@][start:]end in engineering units, fe. 10, 10:, ~:10, 10:20, @10:20."}
+}
+
+func (c *checkPowerGen) Run(ctx context.Context, p checkParams) *checkResult {
+	c.checkParams = p
+	// DEBUG
+	logs.Debugf("powergen params: %# v", pretty.Formatter(c.subParams))
 
-	// Initialize new check object
-	check := icingahelper.NewCheck("GEN")
+	// Initialize new check result
+	check := newResult("GEN")
 
-	// Exit if no host ip submitted
-	if net.ParseIP(c.devParams.Ip) == nil {
-		log.Println("error: valid host ip is required")
-		os.Exit(check.RetVal())
+	types, err := genCheckTypes(c.subParams.ctype)
+	if err != nil {
+		return check.Fail(err)
 	}
 
-	md := c.initDevice()
+	md, err := c.initDevice(ctx)
+	if err != nil {
+		return check.Fail(err)
+	}
 
 	d, ok := md.(godevman.DevGenReader)
 	if !ok {
-		log.Println("error: power generator state check is not supported on this device type")
-		os.Exit(check.RetVal())
+		return check.Fail(fmt.Errorf("power generator state check is not supported on this device type"))
 	}
 
-	switch c.subParams.ctype {
-	case "common":
-		res, err := c.getInfo(d, "Common")
-		if err != nil {
-			log.Printf("error: %v", err)
-			os.Exit(check.RetVal())
+	// Fetch all needed data with one request.
+	// Frozen telemetry detection needs engine state and voltages of all sections
+	target := "All"
+	if len(types) == 1 && c.subParams.frozen == 0 {
+		target = genSections[types[0]]
+	}
+
+	res, err := c.getInfo(ctx, d, target)
+	if err != nil && target == "All" {
+		// Some controllers fail request of all info, fe. il4-30 has no mains voltage OIDs
+		logs.Warnf("%v. Fetching generator info by section", err)
+		res, err = c.getInfoBySection(ctx, d, types)
+	}
+	if err != nil {
+		return check.Fail(err)
+	}
+
+	if c.stateful() {
+		c.loadState()
+	}
+
+	if err := c.evaluate(types, check, res); err != nil {
+		// Partial evaluation must not leave OK state
+		check.SetRetVal(3)
+		return check.Fail(err)
+	}
+
+	if c.subParams.frozen > 0 {
+		if err := c.frozenTelemetry(check, res); err != nil {
+			logs.Errorf("%v", err)
+			check.Raise(3)
+			check.AddMsg(3, "Telemetry Frozen: Na", err.Error())
 		}
-		c.common(check, res)
-	case "electrical":
-		res, err := c.getInfo(d, "Electrical")
-		if err != nil {
-			os.Exit(check.RetVal())
+	}
+
+	// Evaluated results are kept if state can not be saved
+	if c.stateful() {
+		if err := c.saveState(res); err != nil {
+			logs.Errorf("%v", err)
+			check.Raise(3)
+			check.AddMsg(3, "State: save failed", err.Error())
 		}
-		err = c.electrical(check, res)
-		if err != nil {
-			log.Printf("error: %v", err)
-			os.Exit(check.RetVal())
+	}
+
+	return check
+}
+
+// Evaluate check type sections of generator info. Multiple sections are evaluated separately and merged to result
+func (c *checkPowerGen) evaluate(types []string, check *checkResult, res godevman.GenInfo) error {
+	if len(types) == 1 {
+		return c.section(types[0], check, res)
+	}
+
+	// Power situation needs both common and electrical info
+	power := inList(types, "common") && inList(types, "electrical")
+	if power {
+		c.situation = c.powerSituation(res)
+	}
+
+	for _, t := range types {
+		sc := newResult(genSections[t])
+		if err := c.section(t, sc, res); err != nil {
+			return err
 		}
+		check.Merge(sc)
+	}
+
+	if power {
+		c.reportPower(check)
+	}
+
+	return nil
+}
+
+// Evaluate one check type section of generator info
+func (c *checkPowerGen) section(t string, check *checkResult, i godevman.GenInfo) error {
+	switch t {
+	case "common":
+		c.common(check, i)
+		return nil
+	case "electrical":
+		return c.electrical(check, i)
 	case "engine":
-		res, err := c.getInfo(d, "Engine")
-		if err != nil {
-			os.Exit(check.RetVal())
+		return c.engine(check, i)
+	}
+
+	return fmt.Errorf("unknown check type - %s", t)
+}
+
+// Check types and corresponding godevman GeneratorInfo targets
+var genSections = map[string]string{
+	"common":     "Common",
+	"electrical": "Electrical",
+	"engine":     "Engine",
+}
+
+// Order of check types in output
+var genSectionOrder = []string{"common", "electrical", "engine"}
+
+// Parse comma separated list of check types. "all" means all types.
+// Returns types in output order without duplicates.
+func genCheckTypes(ctype string) ([]string, error) {
+	want := map[string]bool{}
+	for _, t := range strings.Split(ctype, ",") {
+		t = strings.TrimSpace(t)
+		switch {
+		case t == "all":
+			for _, s := range genSectionOrder {
+				want[s] = true
+			}
+		case genSections[t] != "":
+			want[t] = true
+		default:
+			return nil, fmt.Errorf("unknown check type - %s", t)
 		}
-		err = c.engine(check, res)
-		if err != nil {
-			log.Printf("error: %v", err)
-			os.Exit(check.RetVal())
+	}
+
+	var out []string
+	for _, s := range genSectionOrder {
+		if want[s] {
+			out = append(out, s)
 		}
-	default:
-		log.Printf("error: unknown check type - %s", c.subParams.ctype)
-		os.Exit(check.RetVal())
 	}
 
-	fmt.Print(check.FinalMsg())
-	os.Exit(check.RetVal())
+	return out, nil
 }
 
-func (c *checkPowerGen) initSubParams() {
-	flag := flag.NewFlagSet("power_gen", flag.ExitOnError)
-	var t = flag.String("t", "", "<check type>\n"+
+func (c *checkPowerGen) SetFlags(flag *flag.FlagSet) {
+	flag.StringVar(&c.subParams.ctype, "t", "", "<check type> or comma separated list of types\n"+
 		"\telectrical - check electrical parameters\n"+
 		"\tengine - check engine parameters\n"+
-		"\tcommon - check common status\n",
+		"\tcommon - check common status\n"+
+		"\tall - all of above with one device request\n",
 	)
-	var wv = flag.String("wv", "215:245", "[warning level for mains and gen. voltage] (V). ctype - electrical")
-	var cv = flag.String("cv", "210:250", "[critical level for mains and gen. voltage] (V). ctype - electrical")
-	var wc = flag.String("wc", "24", "[warning level for gen. current] (A). ctype - electrical")
-	var cc = flag.String("cc", "27", "[critical level for gen. current] (A). ctype - electrical")
-	var wp = flag.String("wp", "13", "[warning level for gen. power] (kW). ctype - electrical")
-	var cp = flag.String("cp", "15", "[critical level for gen. power] (kW). ctype - electrical")
-	var wf = flag.String("wf", "48:52", "[warning level for gen. freq.] (Hz). ctype - electrical")
-	var cf = flag.String("cf", "46:54", "[critical level for gen. freq.] (Hz). ctype - electrical")
-	var wb = flag.String("wb", "130:145", "[warning level for battery voltage] (V*10). ctype - engine")
-	var cb = flag.String("cb", "120:155", "[critical level for battery voltage] (V*10). ctype - engine")
-	var wl = flag.String("wl", "20:100", "[warning level for fuel level] (%). ctype - engine")
-	var cl = flag.String("cl", "10:100", "[critical level for fuel level] (%). ctype - engine")
-	var wt = flag.String("wt", "98", "[warning level for coolant temp] (°C). ctype - engine")
-	var ct = flag.String("ct", "104", "[critical level for coolant temp] (°C). ctype - engine")
-	var info = flag.Bool("info", false, "About check")
-
-	flag.Parse(c.subArgs)
-
-	c.subParams.ctype = *t
-	c.subParams.wVolt = *wv
-	c.subParams.cVolt = *cv
-	c.subParams.wCur = *wc
-	c.subParams.cCur = *cc
-	c.subParams.wPow = *wp
-	c.subParams.cPow = *cp
-	c.subParams.wFreq = *wf
-	c.subParams.cFreq = *cf
-	c.subParams.wBat = *wb
-	c.subParams.cBat = *cb
-	c.subParams.wFuel = *wl
-	c.subParams.cFuel = *cl
-	c.subParams.wTemp = *wt
-	c.subParams.cTemp = *ct
-	// DEBUG
-	if c.dbg {
-		fmt.Printf("powergen params: %# v\n", pretty.Formatter(c))
+	flag.Var(&c.subParams.modePolicy, "mode", "[generator mode state policy] as <value>=<state>[,...], fe. Man=warning,*=critical.\n"+
+		"\tStates: ok|warning|critical|unknown. Overrides -policy. ctype - common (default Auto=ok,*=critical)")
+	flag.Var(&c.subParams.breakerPolicy, "breaker", "[breaker state policy] as <value>=<state>[,...]. Overrides -policy. ctype - common\n"+
+		"\t(default MainsOper=ok,BrksOff=ok,*=critical)")
+	flag.Var(&c.subParams.enginePolicy, "engine", "[engine state policy] as <value>=<state>[,...]. Overrides -policy. ctype - common\n"+
+		"\t(default Ready=ok,*=critical)")
+	flag.Var(&c.subParams.powerPolicy, "power", "[power situation policy] as <situation>=<state>[,...]. Situations: mains|generator|transfer|blackout.\n"+
+		"\tOverrides -policy. Evaluated if common and electrical are checked together\n"+
+		"\t(default mains=ok,generator=warning,transfer=warning,blackout=critical)")
+	flag.Var(&c.subParams.policy, "policy", "[state policy file] YAML file with mode, breaker, engine and power maps of <value>: <state>")
+	thresholdVar(flag, &c.subParams.wVolt, "wv", "215:245", "[warning level for mains and gen. voltage] (V). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cVolt, "cv", "210:250", "[critical level for mains and gen. voltage] (V). ctype - electrical")
+	flag.Var(&c.subParams.wMainsVolt, "wmv", "[warning level for mains voltage] (V). Overrides -wv. One range or comma separated L1,L2,L3 ranges. ctype - electrical")
+	flag.Var(&c.subParams.cMainsVolt, "cmv", "[critical level for mains voltage] (V). Overrides -cv. One range or comma separated L1,L2,L3 ranges. ctype - electrical")
+	flag.Var(&c.subParams.wGenVolt, "wgv", "[warning level for gen. voltage] (V). Overrides -wv. One range or comma separated L1,L2,L3 ranges. ctype - electrical")
+	flag.Var(&c.subParams.cGenVolt, "cgv", "[critical level for gen. voltage] (V). Overrides -cv. One range or comma separated L1,L2,L3 ranges. ctype - electrical")
+	c.subParams.phases = 3
+	flag.Var(&c.subParams.phases, "phases", "[number of phases] (1|3). L2 and L3 are skipped on single phase generator. ctype - electrical")
+	thresholdVar(flag, &c.subParams.wCur, "wc", "24", "[warning level for gen. current] (A). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cCur, "cc", "27", "[critical level for gen. current] (A). ctype - electrical")
+	thresholdVar(flag, &c.subParams.wVoltUnb, "wvu", "2", "[warning level for mains and gen. voltage unbalance] (%). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cVoltUnb, "cvu", "4", "[critical level for mains and gen. voltage unbalance] (%). ctype - electrical")
+	thresholdVar(flag, &c.subParams.wCurUnb, "wcu", "10", "[warning level for gen. current unbalance] (%). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cCurUnb, "ccu", "20", "[critical level for gen. current unbalance] (%). ctype - electrical")
+	flag.Float64Var(&c.subParams.minCurUnb, "mcu", 5, "[minimal mean gen. current for current unbalance] (A). ctype - electrical")
+	thresholdVar(flag, &c.subParams.wPow, "wp", "13", "[warning level for gen. power] (kW). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cPow, "cp", "15", "[critical level for gen. power] (kW). ctype - electrical")
+	flag.Float64Var(&c.subParams.ratedKW, "rated-kw", 0, "[rated power of generator] (kW). Gen. power is evaluated in percents by -wpl and -cpl. ctype - electrical")
+	flag.Float64Var(&c.subParams.ratedA, "rated-a", 0, "[rated current of generator] (A). Gen. current is evaluated in percents by -wcl and -ccl. ctype - electrical")
+	thresholdVar(flag, &c.subParams.wPowLoad, "wpl", "80", "[warning level for gen. power of rated power] (%). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cPowLoad, "cpl", "100", "[critical level for gen. power of rated power] (%). ctype - electrical")
+	thresholdVar(flag, &c.subParams.wCurLoad, "wcl", "80", "[warning level for gen. current of rated current] (%). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cCurLoad, "ccl", "100", "[critical level for gen. current of rated current] (%). ctype - electrical")
+	thresholdVar(flag, &c.subParams.wFreq, "wf", "48:52", "[warning level for gen. freq.] (Hz). ctype - electrical")
+	thresholdVar(flag, &c.subParams.cFreq, "cf", "46:54", "[critical level for gen. freq.] (Hz). ctype - electrical")
+	thresholdVar(flag, &c.subParams.wBat, "wb", "13.0:14.5", "[warning level for battery voltage] (V). ctype - engine")
+	thresholdVar(flag, &c.subParams.cBat, "cb", "12.0:15.5", "[critical level for battery voltage] (V). ctype - engine")
+	thresholdVar(flag, &c.subParams.wFuel, "wl", "20:100", "[warning level for fuel level] (%). ctype - engine")
+	thresholdVar(flag, &c.subParams.cFuel, "cl", "10:100", "[critical level for fuel level] (%). ctype - engine")
+	flag.Float64Var(&c.subParams.tank, "tank", 0, "[fuel tank capacity] (l). Enables fuel autonomy estimation of running engine. ctype - engine")
+	flag.DurationVar(&c.subParams.fuelWindow, "fuel-window", time.Hour, "[fuel consumption estimation window]. ctype - engine")
+	thresholdVar(flag, &c.subParams.wAut, "wa", "4:", "[warning level for fuel autonomy] (h). ctype - engine")
+	thresholdVar(flag, &c.subParams.cAut, "ca", "2:", "[critical level for fuel autonomy] (h). ctype - engine")
+	flag.Float64Var(&c.subParams.service.Hours, "service-hours", 0, "[running hours at last service] (h). ctype - engine")
+	flag.Var(&c.subParams.service.Date, "service-date", "[date of last service] (YYYY-MM-DD). ctype - engine")
+	flag.Float64Var(&c.subParams.service.Interval, "service-interval", 0, "[service interval in running hours] (h). Enables maintenance alarms. ctype - engine")
+	flag.IntVar(&c.subParams.service.Days, "service-days", 0, "[service interval in days]. Needs -service-date. ctype - engine")
+	flag.Var(&c.subParams.serviceFile, "service-file", "[service file] YAML file with hours, date, interval and interval_days of last service by host.\n"+
+		"\tFlags override file values. ctype - engine")
+	thresholdVar(flag, &c.subParams.wService, "ws", "25:", "[warning level for hours until service] (h). ctype - engine")
+	thresholdVar(flag, &c.subParams.cService, "cs", "0:", "[critical level for hours until service] (h). ctype - engine")
+	thresholdVar(flag, &c.subParams.wServiceDays, "wsd", "14:", "[warning level for days until service]. ctype - engine")
+	thresholdVar(flag, &c.subParams.cServiceDays, "csd", "0:", "[critical level for days until service]. ctype - engine")
+	flag.BoolVar(&c.subParams.detectStarts, "detect-starts", false, "Warn if engine has started outside exercise windows since previous check. ctype - engine")
+	flag.Var(&c.subParams.exercise, "exercise", "[exercise windows] as <days> <HH:MM>-<HH:MM>[;...], fe. \"Mon,Thu 10:00-10:30\".\n"+
+		"\tDays: Mon..Sun or * for every day. Enables -detect-starts. ctype - engine")
+	flag.DurationVar(&c.subParams.eventHold, "event-hold", time.Hour, "[event alarm hold period] of unexpected starts and fuel drops. 0 alarms only once. ctype - engine")
+	flag.BoolVar(&c.subParams.detectCycling, "detect-cycling", false, "Alarm on short average run time per start within cycling window. ctype - engine")
+	flag.DurationVar(&c.subParams.cycleWindow, "cycle-window", 24*time.Hour, "[short-cycling detection window]. ctype - engine")
+	flag.Uint64Var(&c.subParams.cycleStarts, "cycle-starts", 3, "[minimal number of starts in window] for short-cycling alarms. ctype - engine")
+	thresholdVar(flag, &c.subParams.wRunTime, "wrt", "15:", "[warning level for average run time per start] (min). ctype - engine")
+	thresholdVar(flag, &c.subParams.cRunTime, "crt", "", "[critical level for average run time per start] (min). ctype - engine")
+	flag.BoolVar(&c.subParams.detectFuel, "detect-fuel", false, "Alarm on fuel level drop of stopped engine and report refuels. ctype - engine")
+	flag.Float64Var(&c.subParams.refuelMin, "refuel-min", 5, "[minimal fuel level rise of refuel] (%). ctype - engine")
+	thresholdVar(flag, &c.subParams.wFuelDrop, "wfd", "3", "[warning level for fuel level drop of stopped engine] (%). ctype - engine")
+	thresholdVar(flag, &c.subParams.cFuelDrop, "cfd", "10", "[critical level for fuel level drop of stopped engine] (%). ctype - engine")
+	flag.DurationVar(&c.subParams.frozen, "frozen", 0, "[frozen telemetry period] Alarm if all values stay identical for period while they should change, fe. 2h.\n"+
+		"\tAll generator info is fetched. 0 disables")
+	c.subParams.frozenState = 3
+	flag.Var(&c.subParams.frozenState, "frozen-state", "Alarm [state] of frozen telemetry - warning|unknown")
+	thresholdVar(flag, &c.subParams.wTemp, "wt", "~:98", "[warning level for coolant temp] (°C). ctype - engine")
+	thresholdVar(flag, &c.subParams.cTemp, "ct", "~:104", "[critical level for coolant temp] (°C). ctype - engine")
+}
+
+// Evaluate gen. current or power. If rated capacity is set, value is evaluated in percents
+// of rated capacity by load thresholds and load is added to performance data.
+func (c *checkPowerGen) genLoad(check *checkResult, k string, s godevman.SensorVal, rated float64, w, cr, wl, cl threshold) {
+	if rated <= 0 {
+		level := c.electricalAlarm(check, k, sensorValue(s), w, cr)
+		check.AddMsg(level, fmt.Sprintf("%s: %s%s", k, formatNum(sensorValue(s)), s.Unit), "")
+		check.AddPerf(sensorPerf(k, s).Thresholds(w, cr).Min(0))
+		return
 	}
 
-	// Show info about check
-	if *info {
-		if i, ok := checksInfo[c.checkName]; ok {
-			fmt.Printf("%s: %s\n", c.checkName, strings.Join(i, "\n"))
-		} else {
-			fmt.Printf("%s: no additional information\n", c.checkName)
-		}
-		os.Exit(3)
+	load := round(sensorValue(s)/rated*100, 1)
+	level := c.electricalAlarm(check, k, load, wl, cl)
+	check.AddMsg(level, fmt.Sprintf("%s: %s%s (%.1f%%)", k, formatNum(sensorValue(s)), s.Unit, load), "")
+	check.AddPerf(sensorPerf(k, s).Range(0, rated))
+	check.AddPerf(newPerf(k+" Load", load).Unit("%").Thresholds(wl, cl).Min(0))
+}
+
+// Number of generator phases. Implements flag.Value, only 1 and 3 are valid
+type phaseCount int
+
+func (p *phaseCount) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil || (n != 1 && n != 3) {
+		return fmt.Errorf("not valid number of phases - %s: expected 1 or 3", s)
 	}
+	*p = phaseCount(n)
+
+	return nil
 }
 
-func (c *checkPowerGen) getInfo(d godevman.DevGenReader, t string) (godevman.GenInfo, error) {
-	res, err := d.GeneratorInfo([]string{t})
+func (p *phaseCount) String() string {
+	return strconv.Itoa(int(*p))
+}
+
+func (c *checkPowerGen) getInfo(ctx context.Context, d godevman.DevGenReader, t string) (godevman.GenInfo, error) {
+	logs.Infof("fetch generator %s info", t)
+	res, err := fetch(ctx, &c.checkParams, "generator info", func() (godevman.GenInfo, error) {
+		return d.GeneratorInfo([]string{t})
+	})
 	if err != nil {
 		return res, err
 	}
 	// DEBUG
-	if c.dbg {
-		fmt.Printf("powergen %s info: %# v\n", t, pretty.Formatter(res))
-	}
+	logs.Debugf("powergen %s info: %# v", t, pretty.Formatter(res))
 	return res, err
 }
 
-func (c *checkPowerGen) common(check *icingahelper.IcingaCheck, i godevman.GenInfo) {
-	check.SetRetVal(0)
-	if i.GenMode.IsSet {
-		val := i.GenMode.Value
-		if val != "Auto" {
-			check.SetRetVal(2)
+// Fetch generator info of needed sections separately and merge them.
+// All sections are fetched if frozen telemetry detection is enabled.
+func (c *checkPowerGen) getInfoBySection(ctx context.Context, d godevman.DevGenReader, types []string) (godevman.GenInfo, error) {
+	var out godevman.GenInfo
+	for _, s := range genSectionOrder {
+		if c.subParams.frozen == 0 && !inList(types, s) {
+			continue
 		}
-		check.AddMsg(check.RetVal(), fmt.Sprintf("Mode: %s", val), "")
-	} else {
-		level := check.RetVal()
-		if level != 2 {
-			level = 3
-		}
-		check.SetRetVal(level)
-		check.AddMsg(level, "Mode: Na", "")
-	}
 
-	if i.BreakerState.IsSet {
-		val := i.BreakerState.Value
-		if val != "MainsOper" && val != "BrksOff" {
-			check.SetRetVal(2)
+		i, err := c.getInfo(ctx, d, genSections[s])
+		if err != nil {
+			return out, err
 		}
-		check.AddMsg(check.RetVal(), fmt.Sprintf("Breaker: %s", val), "")
-	} else {
-		level := check.RetVal()
-		if level != 2 {
-			level = 3
+
+		switch s {
+		case "common":
+			out.GenMode, out.BreakerState, out.EngineState = i.GenMode, i.BreakerState, i.EngineState
+		case "electrical":
+			out.GenVoltL1, out.GenVoltL2, out.GenVoltL3 = i.GenVoltL1, i.GenVoltL2, i.GenVoltL3
+			out.GenCurrentL1, out.GenCurrentL2, out.GenCurrentL3 = i.GenCurrentL1, i.GenCurrentL2, i.GenCurrentL3
+			out.GenPower, out.GenFreq = i.GenPower, i.GenFreq
+			out.MainsVoltL1, out.MainsVoltL2, out.MainsVoltL3 = i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3
+		case "engine":
+			out.RunHours, out.NumStarts, out.BatteryVolt = i.RunHours, i.NumStarts, i.BatteryVolt
+			out.FuelLevel, out.FuelConsum, out.CoolantTemp = i.FuelLevel, i.FuelConsum, i.CoolantTemp
 		}
-		check.SetRetVal(level)
-		check.AddMsg(level, "Breaker: Na", "")
 	}
 
-	if i.EngineState.IsSet {
-		val := i.EngineState.Value
-		if val != "Ready" {
-			check.SetRetVal(2)
-		}
-		check.AddMsg(check.RetVal(), fmt.Sprintf("Engine: %s", val), "")
-	} else {
-		level := check.RetVal()
-		if level != 2 {
-			level = 3
+	return out, nil
+}
+
+// Evaluate generator mode, breaker and engine state using state policy
+func (c *checkPowerGen) common(check *checkResult, i godevman.GenInfo) {
+	check.SetRetVal(0)
+	p := c.subParams
+	items := []struct {
+		name     string
+		val      godevman.ValString
+		policies []statePolicy
+	}{
+		{"Mode", i.GenMode, []statePolicy{p.modePolicy, p.policy.policy.Mode, defaultGenPolicy.Mode}},
+		{"Breaker", i.BreakerState, []statePolicy{p.breakerPolicy, p.policy.policy.Breaker, defaultGenPolicy.Breaker}},
+		{"Engine", i.EngineState, []statePolicy{p.enginePolicy, p.policy.policy.Engine, defaultGenPolicy.Engine}},
+	}
+
+	for _, it := range items {
+		if !it.val.IsSet {
+			check.Raise(3)
+			check.AddMsg(3, fmt.Sprintf("%s: Na", it.name), "")
+			continue
 		}
-		check.SetRetVal(level)
-		check.AddMsg(level, "Engine: Na", "")
+
+		level := policyState(it.val.Value, it.policies...)
+		check.Raise(level)
+		check.AddMsg(level, fmt.Sprintf("%s: %s", it.name, it.val.Value), "")
 	}
 }
 
-func (c *checkPowerGen) electrical(check *icingahelper.IcingaCheck, i godevman.GenInfo) error {
+func (c *checkPowerGen) electrical(check *checkResult, i godevman.GenInfo) error {
 	data := map[string]godevman.SensorVal{
 		"Mains Voltage L1": i.MainsVoltL1,
 		"Mains Voltage L2": i.MainsVoltL2,
@@ -218,70 +426,62 @@ func (c *checkPowerGen) electrical(check *icingahelper.IcingaCheck, i godevman.G
 	sort.Strings(keys)
 
 	for _, k := range keys {
+		if c.subParams.phases == 1 && phaseNum(k) > 1 {
+			continue
+		}
+
 		switch data[k].Unit {
 		// Only il-14 has mains voltage
 		case "NotSupported":
 			if !data[k].IsSet {
 				check.AddMsg(0, fmt.Sprintf("%s: NotSupported", k), "")
 			}
+		// Mains voltage is not marked as NotSupported when all info is requested
+		case "":
+			if data[k].IsSet || !strings.Contains(k, "Mains") {
+				return fmt.Errorf("unexpected results from godevman")
+			}
+			check.AddMsg(0, fmt.Sprintf("%s: NotSupported", k), "")
 		case "V":
 			if data[k].IsSet {
 				val := data[k].Value
+				w, cr := c.voltThresholds(k)
 				level := 0
-				if strings.Contains(k, "Mains") || val != 0 {
-					l, err := check.AlarmLevel(int64(val), c.subParams.wVolt, c.subParams.cVolt)
-					if err != nil {
-						return fmt.Errorf("voltage alarm level error: %v", err)
-					}
-					level = l
+				// Stopped generator has zero voltage
+				if strings.Contains(k, "Mains") || val != 0 || c.situation == powerGenerator {
+					level = c.electricalAlarm(check, k, sensorValue(data[k]), w, cr)
 				}
 
-				check.AddMsg(level, fmt.Sprintf("%s: %dV", k, val), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), "", c.subParams.wVolt, c.subParams.wVolt, "0", "")
+				check.AddMsg(level, fmt.Sprintf("%s: %sV", k, formatNum(sensorValue(data[k]))), "")
+				check.AddPerf(sensorPerf(k, data[k]).Thresholds(w, cr).Min(0))
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
 		case "A":
 			if data[k].IsSet {
-				val := data[k].Value
-				level, err := check.AlarmLevel(int64(val), c.subParams.wCur, c.subParams.cCur)
-				if err != nil {
-					return fmt.Errorf("current alarm level error: %v", err)
-				}
-
-				check.AddMsg(level, fmt.Sprintf("%s: %dA", k, val), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), "", c.subParams.wCur, c.subParams.cCur, "0", "")
+				p := c.subParams
+				c.genLoad(check, k, data[k], p.ratedA, p.wCur, p.cCur, p.wCurLoad, p.cCurLoad)
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
 		case "kW":
 			if data[k].IsSet {
-				val := data[k].Value
-				level, err := check.AlarmLevel(int64(val), c.subParams.wPow, c.subParams.cPow)
-				if err != nil {
-					return fmt.Errorf("power alarm level error: %v", err)
-				}
-
-				check.AddMsg(level, fmt.Sprintf("%s: %dkW", k, val), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), "", c.subParams.wPow, c.subParams.cPow, "0", "")
+				p := c.subParams
+				c.genLoad(check, k, data[k], p.ratedKW, p.wPow, p.cPow, p.wPowLoad, p.cPowLoad)
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
 		case "Hz":
 			if data[k].IsSet {
-				val := data[k].Value
+				w, cr := c.subParams.wFreq, c.subParams.cFreq
 				level := 0
-				if val != 0 {
-					l, err := check.AlarmLevel(int64(val), c.subParams.wFreq, c.subParams.cFreq)
-					if err != nil {
-						return fmt.Errorf("power alarm level error: %v", err)
-					}
-					level = l
+				if data[k].Value != 0 || c.situation == powerGenerator {
+					level = c.electricalAlarm(check, k, sensorValue(data[k]), w, cr)
 				}
 
-				rVal := float64(val) / float64(data[k].Divisor)
+				rVal := sensorValue(data[k])
 				check.AddMsg(level, fmt.Sprintf("%s: %.1fHz", k, rVal), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), "", c.subParams.wFreq, c.subParams.cFreq, "0", "")
+				check.AddPerf(sensorPerf(k, data[k]).Thresholds(w, cr).Min(0))
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
@@ -290,10 +490,106 @@ func (c *checkPowerGen) electrical(check *icingahelper.IcingaCheck, i godevman.G
 		}
 	}
 
+	if c.subParams.phases == 3 {
+		c.unbalance(check, data)
+	}
+
 	return nil
 }
 
-func (c *checkPowerGen) engine(check *icingahelper.IcingaCheck, i godevman.GenInfo) error {
+// Phase unbalance groups. Phase sensor names are group sensor name with L1-L3 suffix
+var unbalanceGroups = []struct {
+	name, sensor string
+	volt         bool
+}{
+	{"Gen Current Unbalance", "Gen Current", false},
+	{"Gen Voltage Unbalance", "Gen Voltage", true},
+	{"Mains Voltage Unbalance", "Mains Voltage", true},
+}
+
+// Evaluate unbalance of three phase voltages and currents.
+// Group is skipped if some phase value is missing or all phases are zero.
+// Current unbalance is skipped if mean current is below minimal current.
+func (c *checkPowerGen) unbalance(check *checkResult, data map[string]godevman.SensorVal) {
+	for _, g := range unbalanceGroups {
+		vals := make([]float64, 0, 3)
+		for n := 1; n <= 3; n++ {
+			if s := data[fmt.Sprintf("%s L%d", g.sensor, n)]; s.IsSet {
+				vals = append(vals, sensorValue(s))
+			}
+		}
+
+		u, ok := unbalancePct(vals)
+		if !ok {
+			continue
+		}
+		// Current unbalance of lightly loaded generator is meaningless
+		if !g.volt && (vals[0]+vals[1]+vals[2])/3 < c.subParams.minCurUnb {
+			continue
+		}
+
+		w, cr := c.subParams.wCurUnb, c.subParams.cCurUnb
+		if g.volt {
+			w, cr = c.subParams.wVoltUnb, c.subParams.cVoltUnb
+		}
+		level := c.electricalAlarm(check, g.name, u, w, cr)
+
+		check.AddMsg(level, fmt.Sprintf("%s: %.1f%%", g.name, u), "")
+		check.AddPerf(newPerf(g.name, u).Unit("%").Thresholds(w, cr).Min(0))
+	}
+}
+
+// Returns unbalance of three phase values as max deviation from mean in percents of mean.
+// Result is rounded to two decimals. Returns false if there are not three values or mean is zero.
+func unbalancePct(v []float64) (float64, bool) {
+	if len(v) != 3 {
+		return 0, false
+	}
+
+	mean := (v[0] + v[1] + v[2]) / 3
+	if mean == 0 {
+		return 0, false
+	}
+
+	dev := 0.0
+	for _, x := range v {
+		dev = math.Max(dev, math.Abs(x-mean))
+	}
+
+	return round(dev/mean*100, 2), true
+}
+
+// Returns voltage thresholds of sensor. Mains and gen. specific thresholds override common ones
+func (c *checkPowerGen) voltThresholds(k string) (threshold, threshold) {
+	w, cr := c.subParams.wVolt, c.subParams.cVolt
+	pw, pc := c.subParams.wGenVolt, c.subParams.cGenVolt
+	if strings.HasPrefix(k, "Mains") {
+		pw, pc = c.subParams.wMainsVolt, c.subParams.cMainsVolt
+	}
+
+	n := phaseNum(k)
+	if pw.set {
+		w = pw.phase(n)
+	}
+	if pc.set {
+		cr = pc.phase(n)
+	}
+
+	return w, cr
+}
+
+// Returns phase number from sensor name suffix L1-L3. 0 if sensor is not phase specific
+func phaseNum(k string) int {
+	for n := 1; n <= 3; n++ {
+		if strings.HasSuffix(k, fmt.Sprintf("L%d", n)) {
+			return n
+		}
+	}
+
+	return 0
+}
+
+func (c *checkPowerGen) engine(check *checkResult, i godevman.GenInfo) error {
 	data := map[string]godevman.SensorVal{
 		"Running Hours":       i.RunHours,
 		"Fuel level":          i.FuelLevel,
@@ -313,55 +609,49 @@ func (c *checkPowerGen) engine(check *icingahelper.IcingaCheck, i godevman.GenIn
 		switch k {
 		case "Battery Voltage":
 			if data[k].IsSet {
-				val := data[k].Value
-				level, err := check.AlarmLevel(int64(val), c.subParams.wBat, c.subParams.cBat)
-				if err != nil {
-					return fmt.Errorf("voltage alarm level error: %v", err)
-				}
+				w, cr := c.subParams.wBat, c.subParams.cBat
+				rVal := sensorValue(data[k])
+				level := check.AlarmLevel(rVal, w, cr)
 
-				rVal := float64(val) / float64(data[k].Divisor)
 				check.AddMsg(level, fmt.Sprintf("%s: %.1fV", k, rVal), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), "", c.subParams.wBat, c.subParams.cBat, "0", "")
+				check.AddPerf(sensorPerf(k, data[k]).Thresholds(w, cr).Min(0))
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
 		case "Coolant Temperature":
 			if data[k].IsSet {
-				val := data[k].Value
-				level, err := check.AlarmLevel(int64(val), c.subParams.wTemp, c.subParams.cTemp)
-				if err != nil {
-					return fmt.Errorf("coolant alarm level error: %v", err)
-				}
+				w, cr := c.subParams.wTemp, c.subParams.cTemp
+				rVal := sensorValue(data[k])
+				level := check.AlarmLevel(rVal, w, cr)
 
-				check.AddMsg(level, fmt.Sprintf("%s: %d%s", k, val, data[k].Unit), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), "", c.subParams.wTemp, c.subParams.cTemp, "0", "")
+				check.AddMsg(level, fmt.Sprintf("%s: %s%s", k, formatNum(rVal), data[k].Unit), "")
+				check.AddPerf(sensorPerf(k, data[k]).Thresholds(w, cr))
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
 		case "Fuel level":
 			if data[k].IsSet {
-				val := data[k].Value
-				level, err := check.AlarmLevel(int64(val), c.subParams.wFuel, c.subParams.cFuel)
-				if err != nil {
-					return fmt.Errorf("fuel alarm level error: %v", err)
-				}
+				w, cr := c.subParams.wFuel, c.subParams.cFuel
+				rVal := sensorValue(data[k])
+				level := check.AlarmLevel(rVal, w, cr)
 
-				check.AddMsg(level, fmt.Sprintf("%s: %d%s", k, val, data[k].Unit), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), data[k].Unit, c.subParams.wFuel, c.subParams.cFuel, "0", "")
+				check.AddMsg(level, fmt.Sprintf("%s: %s%s", k, formatNum(rVal), data[k].Unit), "")
+				check.AddPerf(sensorPerf(k, data[k]).Thresholds(w, cr).Range(0, 100))
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
 		default:
 			if data[k].IsSet {
-				val := data[k].Value
+				rVal := sensorValue(data[k])
 
-				rVal := float64(val)
-				if data[k].Divisor != 0 {
-					rVal = rVal / float64(data[k].Divisor)
+				p := sensorPerf(k, data[k]).Min(0)
+				// Running hours only grow
+				if k == "Running Hours" {
+					p = p.Unit("c")
 				}
 
 				check.AddMsg(0, fmt.Sprintf("%s: %.1f%s", k, rVal, data[k].Unit), "")
-				check.AddPerfData(fmt.Sprintf("'%s'", k), strconv.Itoa(int(val)), "", "", "", "0", "")
+				check.AddPerf(p)
 			} else {
 				check.AddMsg(3, fmt.Sprintf("%s: Na", k), "")
 			}
@@ -372,7 +662,7 @@ func (c *checkPowerGen) engine(check *icingahelper.IcingaCheck, i godevman.GenIn
 	if i.NumStarts.IsSet {
 		val := i.NumStarts.Value
 		check.AddMsg(0, fmt.Sprintf("%s: %d", name, val), "")
-		check.AddPerfData(fmt.Sprintf("'%s'", name), strconv.Itoa(int(val)), "", "", "", "0", "")
+		check.AddPerf(newPerf(name, float64(val)).Unit("c").Min(0))
 	} else {
 		level := check.RetVal()
 		if level != 2 {
@@ -381,5 +671,21 @@ func (c *checkPowerGen) engine(check *icingahelper.IcingaCheck, i godevman.GenIn
 		check.AddMsg(level, fmt.Sprintf("%s: Na", name), "")
 	}
 
+	if c.subParams.tank > 0 {
+		c.autonomy(check, i)
+	}
+	if c.maintenanceSet() {
+		c.maintenance(check, i)
+	}
+	if c.startsSet() {
+		c.unexpectedStarts(check, i)
+	}
+	if c.subParams.detectCycling {
+		c.shortCycling(check, i)
+	}
+	if c.subParams.detectFuel {
+		c.fuelEvents(check, i)
+	}
+
 	return nil
 }
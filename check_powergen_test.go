@@ -0,0 +1,709 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+// Returns power_gen check with parsed check arguments
+func newTestPowerGen(t *testing.T, args ...string) *checkPowerGen {
+	t.Helper()
+	c := &checkPowerGen{}
+	fs := flag.NewFlagSet("power_gen", flag.ContinueOnError)
+	c.SetFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("parse args: %v", err)
+	}
+
+	return c
+}
+
+// Sets current time for test
+func setNow(t *testing.T, ts time.Time) {
+	t.Helper()
+	orig := now
+	now = func() time.Time { return ts }
+	t.Cleanup(func() { now = orig })
+}
+
+// Sets current time for test moving forward by millisecond on every call
+func setMovingNow(t *testing.T, ts time.Time) {
+	t.Helper()
+	orig := now
+	now = func() time.Time {
+		ts = ts.Add(time.Millisecond)
+		return ts
+	}
+	t.Cleanup(func() { now = orig })
+}
+
+// Runs stateful power_gen check at given time with state kept in dir
+func runStateful(t *testing.T, dir string, at time.Time, i godevman.GenInfo, args ...string) *checkResult {
+	t.Helper()
+	setNow(t, at)
+	p := checkParams{devices: &fakeDevice{Gen: &i}, stateDir: dir, devParams: godevman.Dparams{Ip: "192.0.2.1"}}
+	res := newTestPowerGen(t, args...).Run(context.Background(), p)
+	if res.Err() != nil {
+		t.Fatalf("%s - unexpected error: %v", at.Format("2006-01-02 15:04"), res.Err())
+	}
+
+	return res
+}
+
+// Returns set sensor value
+func sensor(unit string, val uint64, div int) godevman.SensorVal {
+	return godevman.SensorVal{Unit: unit, Value: val, Divisor: div, IsSet: true}
+}
+
+// Returns set string value
+func valStr(v string) godevman.ValString {
+	return godevman.ValString{Value: v, IsSet: true}
+}
+
+// Returns generator info of healthy generator standing by on mains
+func testGenInfo() godevman.GenInfo {
+	return godevman.GenInfo{
+		GenMode:      valStr("Auto"),
+		BreakerState: valStr("MainsOper"),
+		EngineState:  valStr("Ready"),
+		MainsVoltL1:  sensor("V", 236, 0),
+		MainsVoltL2:  sensor("V", 236, 0),
+		MainsVoltL3:  sensor("V", 242, 0),
+		GenVoltL1:    sensor("V", 0, 0),
+		GenVoltL2:    sensor("V", 0, 0),
+		GenVoltL3:    sensor("V", 0, 0),
+		GenCurrentL1: sensor("A", 0, 0),
+		GenCurrentL2: sensor("A", 0, 0),
+		GenCurrentL3: sensor("A", 0, 0),
+		GenPower:     sensor("kW", 0, 0),
+		GenFreq:      sensor("Hz", 0, 10),
+		RunHours:     sensor("h", 617, 10),
+		NumStarts:    godevman.ValU64{Value: 16, IsSet: true},
+		BatteryVolt:  sensor("V", 136, 10),
+		FuelLevel:    sensor("%", 73, 0),
+		FuelConsum:   sensor("l", 0, 10),
+		CoolantTemp:  sensor("°C", 52, 0),
+	}
+}
+
+func TestPowerGenCommon(t *testing.T) {
+	dir := t.TempDir()
+	policy := filepath.Join(dir, "policy.yaml")
+	data := "mode:\n  Man: warning\nbreaker:\n  IslOper: ok\nengine:\n  Running: ok\n"
+	if err := os.WriteFile(policy, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		modify  func(i *godevman.GenInfo)
+		state   int
+		summary string
+	}{
+		{"ok", nil, func(i *godevman.GenInfo) {}, 0, "Mode: Auto; Breaker: MainsOper; Engine: Ready"},
+		{"breaker off", nil, func(i *godevman.GenInfo) { i.BreakerState = valStr("BrksOff") }, 0,
+			"Mode: Auto; Breaker: BrksOff; Engine: Ready"},
+		{"engine running", nil, func(i *godevman.GenInfo) { i.EngineState = valStr("Running") }, 2,
+			"Engine: Running(c); Mode: Auto; Breaker: MainsOper"},
+		{"engine na", nil, func(i *godevman.GenInfo) { i.EngineState = godevman.ValString{} }, 3,
+			"Engine: Na(u); Mode: Auto; Breaker: MainsOper"},
+		{"all na", nil, func(i *godevman.GenInfo) { *i = godevman.GenInfo{} }, 3,
+			"Mode: Na(u); Breaker: Na(u); Engine: Na(u)"},
+		{"manual mode warning", []string{"-mode", "Man=warning"}, func(i *godevman.GenInfo) { i.GenMode = valStr("Man") }, 1,
+			"Mode: Man(w); Breaker: MainsOper; Engine: Ready"},
+		{"crit not hidden by warning", []string{"-mode", "Man=warning"}, func(i *godevman.GenInfo) {
+			i.GenMode = valStr("Man")
+			i.EngineState = valStr("Shutdown")
+		}, 2, "Engine: Shutdown(c); Mode: Man(w); Breaker: MainsOper"},
+		{"na with warning", []string{"-mode", "Man=warning"}, func(i *godevman.GenInfo) {
+			i.GenMode = valStr("Man")
+			i.EngineState = godevman.ValString{}
+		}, 1, "Mode: Man(w); Engine: Na(u); Breaker: MainsOper"},
+		{"gen supplying load from policy file", []string{"-policy", policy}, func(i *godevman.GenInfo) {
+			i.BreakerState = valStr("IslOper")
+			i.EngineState = valStr("Running")
+		}, 0, "Mode: Auto; Breaker: IslOper; Engine: Running"},
+		{"flag overrides policy file", []string{"-policy", policy, "-engine", "Running=warning"}, func(i *godevman.GenInfo) {
+			i.BreakerState = valStr("IslOper")
+			i.EngineState = valStr("Running")
+		}, 1, "Engine: Running(w); Mode: Auto; Breaker: IslOper"},
+		{"default rule", []string{"-breaker", "*=warning"}, func(i *godevman.GenInfo) { i.BreakerState = valStr("IslOper") }, 1,
+			"Breaker: IslOper(w); Mode: Auto; Engine: Ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := testGenInfo()
+			tt.modify(&i)
+			check := newResult("GEN")
+			newTestPowerGen(t, tt.args...).common(check, i)
+
+			if check.RetVal() != tt.state {
+				t.Errorf("state - expected %d, got %d", tt.state, check.RetVal())
+			}
+			if s := check.Summary(); s != tt.summary {
+				t.Errorf("summary - expected %q, got %q", tt.summary, s)
+			}
+		})
+	}
+}
+
+func TestPowerGenElectrical(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		modify  func(i *godevman.GenInfo)
+		state   int
+		summary string
+		perf    string
+		err     bool
+	}{
+		{
+			name:   "on mains",
+			modify: func(i *godevman.GenInfo) {},
+			state:  0,
+			summary: "Gen Current L1: 0A; Gen Current L2: 0A; Gen Current L3: 0A; Gen Frequency: 0.0Hz; Gen Power: 0kW; " +
+				"Gen Voltage L1: 0V; Gen Voltage L2: 0V; Gen Voltage L3: 0V; Mains Voltage L1: 236V; Mains Voltage L2: 236V; " +
+				"Mains Voltage L3: 242V; Mains Voltage Unbalance: 1.7%",
+			perf: "'Gen Current L1'=0A;24;27;0; 'Gen Current L2'=0A;24;27;0; 'Gen Current L3'=0A;24;27;0; 'Gen Frequency'=0Hz;48:52;46:54;0; " +
+				"'Gen Power'=0kW;13;15;0; 'Gen Voltage L1'=0V;215:245;210:250;0; 'Gen Voltage L2'=0V;215:245;210:250;0; " +
+				"'Gen Voltage L3'=0V;215:245;210:250;0; 'Mains Voltage L1'=236V;215:245;210:250;0; " +
+				"'Mains Voltage L2'=236V;215:245;210:250;0; 'Mains Voltage L3'=242V;215:245;210:250;0; " +
+				"'Mains Voltage Unbalance'=1.68%;2;4;0;",
+		},
+		{
+			name: "mains voltage warning",
+			args: []string{"-wvu", "10", "-cvu", "15"},
+			modify: func(i *godevman.GenInfo) {
+				i.MainsVoltL2 = sensor("V", 212, 0)
+			},
+			state: 1,
+		},
+		{
+			name: "mains voltage critical",
+			modify: func(i *godevman.GenInfo) {
+				i.MainsVoltL2 = sensor("V", 0, 0)
+			},
+			state: 2,
+		},
+		{
+			name: "gen overload",
+			modify: func(i *godevman.GenInfo) {
+				i.GenCurrentL1 = sensor("A", 25, 0)
+				i.GenPower = sensor("kW", 16, 0)
+			},
+			state: 2,
+		},
+		{
+			name: "custom thresholds",
+			args: []string{"-wp", "20", "-cp", "30"},
+			modify: func(i *godevman.GenInfo) {
+				i.GenPower = sensor("kW", 16, 0)
+			},
+			state: 0,
+		},
+		{
+			name: "rated power",
+			args: []string{"-rated-kw", "20", "-wp", "5"},
+			modify: func(i *godevman.GenInfo) {
+				i.GenPower = sensor("kW", 16, 0)
+			},
+			state: 0,
+		},
+		{
+			name: "rated power warning",
+			args: []string{"-rated-kw", "20"},
+			modify: func(i *godevman.GenInfo) {
+				i.GenPower = sensor("kW", 17, 0)
+			},
+			state: 1,
+		},
+		{
+			name: "rated current and power",
+			args: []string{"-phases", "1", "-rated-kw", "20", "-rated-a", "30"},
+			modify: func(i *godevman.GenInfo) {
+				i.GenCurrentL1 = sensor("A", 25, 0)
+				i.GenPower = sensor("kW", 10, 0)
+			},
+			state:   1,
+			summary: "Gen Current L1: 25A (83.3%)(w); Gen Frequency: 0.0Hz; Gen Power: 10kW (50.0%); Gen Voltage L1: 0V; Mains Voltage L1: 236V",
+			perf: "'Gen Current L1'=25A;;;0;30 'Gen Current L1 Load'=83.3%;80;100;0; 'Gen Frequency'=0Hz;48:52;46:54;0; " +
+				"'Gen Power'=10kW;;;0;20 'Gen Power Load'=50%;80;100;0; 'Gen Voltage L1'=0V;215:245;210:250;0; " +
+				"'Mains Voltage L1'=236V;215:245;210:250;0;",
+		},
+		{
+			name: "mains not supported",
+			modify: func(i *godevman.GenInfo) {
+				i.MainsVoltL1 = godevman.SensorVal{Unit: "NotSupported"}
+				i.MainsVoltL2 = godevman.SensorVal{Unit: "NotSupported"}
+				i.MainsVoltL3 = godevman.SensorVal{Unit: "NotSupported"}
+			},
+			state: 0,
+		},
+		{
+			name: "gen frequency ok",
+			modify: func(i *godevman.GenInfo) {
+				i.GenFreq = sensor("Hz", 501, 10)
+			},
+			state: 0,
+		},
+		{
+			name: "gen frequency warning",
+			args: []string{"-wf", "49.5:50.5"},
+			modify: func(i *godevman.GenInfo) {
+				i.GenFreq = sensor("Hz", 494, 10)
+			},
+			state: 1,
+			summary: "Gen Frequency: 49.4Hz(w); Gen Current L1: 0A; Gen Current L2: 0A; Gen Current L3: 0A; Gen Power: 0kW; " +
+				"Gen Voltage L1: 0V; Gen Voltage L2: 0V; Gen Voltage L3: 0V; Mains Voltage L1: 236V; Mains Voltage L2: 236V; " +
+				"Mains Voltage L3: 242V; Mains Voltage Unbalance: 1.7%",
+		},
+		{
+			name: "separate mains and gen voltage thresholds",
+			args: []string{"-wmv", "230:245", "-cmv", "220:250", "-wgv", "225:235", "-cgv", "220:240"},
+			modify: func(i *godevman.GenInfo) {
+				i.GenVoltL1 = sensor("V", 236, 0)
+				i.GenVoltL2 = sensor("V", 230, 0)
+				i.GenVoltL3 = sensor("V", 230, 0)
+			},
+			state: 1,
+			summary: "Gen Voltage L1: 236V(w); Gen Current L1: 0A; Gen Current L2: 0A; Gen Current L3: 0A; Gen Frequency: 0.0Hz; " +
+				"Gen Power: 0kW; Gen Voltage L2: 230V; Gen Voltage L3: 230V; Mains Voltage L1: 236V; Mains Voltage L2: 236V; " +
+				"Mains Voltage L3: 242V; Gen Voltage Unbalance: 1.7%; Mains Voltage Unbalance: 1.7%",
+		},
+		{
+			name: "per phase mains voltage thresholds",
+			args: []string{"-wmv", "230:240,230:240,240:250", "-cmv", ",,241:250"},
+			modify: func(i *godevman.GenInfo) {
+				i.MainsVoltL3 = sensor("V", 240, 0)
+			},
+			state: 2,
+			perf: "'Gen Current L1'=0A;24;27;0; 'Gen Current L2'=0A;24;27;0; 'Gen Current L3'=0A;24;27;0; 'Gen Frequency'=0Hz;48:52;46:54;0; " +
+				"'Gen Power'=0kW;13;15;0; 'Gen Voltage L1'=0V;215:245;210:250;0; 'Gen Voltage L2'=0V;215:245;210:250;0; " +
+				"'Gen Voltage L3'=0V;215:245;210:250;0; 'Mains Voltage L1'=236V;230:240;;0; " +
+				"'Mains Voltage L2'=236V;230:240;;0; 'Mains Voltage L3'=240V;240:250;241:250;0; 'Mains Voltage Unbalance'=1.12%;2;4;0;",
+		},
+		{
+			name: "single phase",
+			args: []string{"-phases", "1"},
+			modify: func(i *godevman.GenInfo) {
+				i.MainsVoltL2 = sensor("V", 0, 0)
+				i.GenCurrentL3 = godevman.SensorVal{Unit: "A"}
+			},
+			state:   0,
+			summary: "Gen Current L1: 0A; Gen Frequency: 0.0Hz; Gen Power: 0kW; Gen Voltage L1: 0V; Mains Voltage L1: 236V",
+			perf: "'Gen Current L1'=0A;24;27;0; 'Gen Frequency'=0Hz;48:52;46:54;0; 'Gen Power'=0kW;13;15;0; " +
+				"'Gen Voltage L1'=0V;215:245;210:250;0; 'Mains Voltage L1'=236V;215:245;210:250;0;",
+		},
+		{
+			name: "current unbalance",
+			modify: func(i *godevman.GenInfo) {
+				i.GenCurrentL1 = sensor("A", 20, 0)
+				i.GenCurrentL2 = sensor("A", 19, 0)
+				i.GenCurrentL3 = sensor("A", 15, 0)
+			},
+			state: 1,
+			summary: "Gen Current Unbalance: 16.7%(w); Gen Current L1: 20A; Gen Current L2: 19A; Gen Current L3: 15A; Gen Frequency: 0.0Hz; " +
+				"Gen Power: 0kW; Gen Voltage L1: 0V; Gen Voltage L2: 0V; Gen Voltage L3: 0V; Mains Voltage L1: 236V; Mains Voltage L2: 236V; " +
+				"Mains Voltage L3: 242V; Mains Voltage Unbalance: 1.7%",
+		},
+		{
+			name: "current unbalance of light load",
+			modify: func(i *godevman.GenInfo) {
+				i.GenCurrentL1 = sensor("A", 1, 0)
+			},
+			state: 0,
+			summary: "Gen Current L1: 1A; Gen Current L2: 0A; Gen Current L3: 0A; Gen Frequency: 0.0Hz; Gen Power: 0kW; " +
+				"Gen Voltage L1: 0V; Gen Voltage L2: 0V; Gen Voltage L3: 0V; Mains Voltage L1: 236V; Mains Voltage L2: 236V; " +
+				"Mains Voltage L3: 242V; Mains Voltage Unbalance: 1.7%",
+		},
+		{
+			name: "current unbalance minimal current",
+			args: []string{"-mcu", "0.3"},
+			modify: func(i *godevman.GenInfo) {
+				i.GenCurrentL1 = sensor("A", 1, 0)
+			},
+			state: 2,
+		},
+		{
+			name: "gen voltage unbalance",
+			modify: func(i *godevman.GenInfo) {
+				i.GenVoltL1 = sensor("V", 230, 0)
+				i.GenVoltL2 = sensor("V", 231, 0)
+				i.GenVoltL3 = sensor("V", 216, 0)
+			},
+			state: 2,
+		},
+		{
+			name: "unbalance not evaluated on missing phase",
+			args: []string{"-cvu", "1"},
+			modify: func(i *godevman.GenInfo) {
+				i.MainsVoltL3 = godevman.SensorVal{Unit: "V"}
+			},
+			state: 0,
+			summary: "Mains Voltage L3: Na(u); Gen Current L1: 0A; Gen Current L2: 0A; Gen Current L3: 0A; Gen Frequency: 0.0Hz; " +
+				"Gen Power: 0kW; Gen Voltage L1: 0V; Gen Voltage L2: 0V; Gen Voltage L3: 0V; Mains Voltage L1: 236V; Mains Voltage L2: 236V",
+		},
+		{
+			name: "current na",
+			modify: func(i *godevman.GenInfo) {
+				i.GenCurrentL3 = godevman.SensorVal{Unit: "A"}
+			},
+			state: 0,
+		},
+		{
+			name: "unexpected unit",
+			modify: func(i *godevman.GenInfo) {
+				i.GenPower = sensor("W", 16, 0)
+			},
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := testGenInfo()
+			tt.modify(&i)
+			check := newResult("GEN")
+			err := newTestPowerGen(t, tt.args...).electrical(check, i)
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if check.RetVal() != tt.state {
+				t.Errorf("state - expected %d, got %d (%s)", tt.state, check.RetVal(), check.Summary())
+			}
+			if tt.summary != "" && check.Summary() != tt.summary {
+				t.Errorf("summary - expected %q, got %q", tt.summary, check.Summary())
+			}
+			if tt.perf != "" && check.PerfData() != tt.perf {
+				t.Errorf("perfdata - expected %q, got %q", tt.perf, check.PerfData())
+			}
+		})
+	}
+}
+
+func TestUnbalancePct(t *testing.T) {
+	tests := []struct {
+		vals []float64
+		exp  float64
+		ok   bool
+	}{
+		{[]float64{230, 230, 230}, 0, true},
+		{[]float64{236, 236, 242}, 1.68, true},
+		{[]float64{20, 19, 15}, 16.67, true},
+		{[]float64{3, 0, 0}, 200, true},
+		{[]float64{0, 0, 0}, 0, false},
+		{[]float64{230, 230}, 0, false},
+	}
+
+	for _, tt := range tests {
+		u, ok := unbalancePct(tt.vals)
+		if u != tt.exp || ok != tt.ok {
+			t.Errorf("%v - expected %v %v, got %v %v", tt.vals, tt.exp, tt.ok, u, ok)
+		}
+	}
+}
+
+func TestPowerGenEngine(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		modify  func(i *godevman.GenInfo)
+		state   int
+		summary string
+		perf    string
+		err     bool
+	}{
+		{
+			name:    "ok",
+			modify:  func(i *godevman.GenInfo) {},
+			state:   0,
+			summary: "Battery Voltage: 13.6V; Coolant Temperature: 52°C; Fuel Consumption: 0.0l; Fuel level: 73%; Running Hours: 61.7h; Number of Starts: 16",
+			perf: "'Battery Voltage'=13.6V;13:14.5;12:15.5;0; 'Coolant Temperature'=52C;~:98;~:104;; 'Fuel Consumption'=0;;;0; " +
+				"'Fuel level'=73%;20:100;10:100;0;100 'Running Hours'=61.7c;;;0; 'Number of Starts'=16c;;;0;",
+		},
+		{
+			name:   "low fuel",
+			modify: func(i *godevman.GenInfo) { i.FuelLevel = sensor("%", 15, 0) },
+			state:  1,
+		},
+		{
+			name:   "empty tank",
+			modify: func(i *godevman.GenInfo) { i.FuelLevel = sensor("%", 5, 0) },
+			state:  2,
+		},
+		{
+			name:   "battery low",
+			modify: func(i *godevman.GenInfo) { i.BatteryVolt = sensor("V", 119, 10) },
+			state:  2,
+		},
+		{
+			name:   "overheat",
+			modify: func(i *godevman.GenInfo) { i.CoolantTemp = sensor("°C", 100, 0) },
+			state:  1,
+		},
+		{
+			name:    "coolant below zero",
+			modify:  func(i *godevman.GenInfo) { i.CoolantTemp = sensor("°C", 5, -1) },
+			state:   0,
+			summary: "Battery Voltage: 13.6V; Coolant Temperature: -5°C; Fuel Consumption: 0.0l; Fuel level: 73%; Running Hours: 61.7h; Number of Starts: 16",
+		},
+		{
+			name:   "battery decimal thresholds",
+			args:   []string{"-wb", "13.7:14.5", "-cb", "12.5:15"},
+			modify: func(i *godevman.GenInfo) {},
+			state:  1,
+			perf: "'Battery Voltage'=13.6V;13.7:14.5;12.5:15;0; 'Coolant Temperature'=52C;~:98;~:104;; 'Fuel Consumption'=0;;;0; " +
+				"'Fuel level'=73%;20:100;10:100;0;100 'Running Hours'=61.7c;;;0; 'Number of Starts'=16c;;;0;",
+		},
+		{
+			name:    "starts na",
+			modify:  func(i *godevman.GenInfo) { i.NumStarts = godevman.ValU64{} },
+			state:   0,
+			summary: "Number of Starts: Na(u); Battery Voltage: 13.6V; Coolant Temperature: 52°C; Fuel Consumption: 0.0l; Fuel level: 73%; Running Hours: 61.7h",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := testGenInfo()
+			tt.modify(&i)
+			check := newResult("GEN")
+			err := newTestPowerGen(t, tt.args...).engine(check, i)
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if check.RetVal() != tt.state {
+				t.Errorf("state - expected %d, got %d (%s)", tt.state, check.RetVal(), check.Summary())
+			}
+			if tt.summary != "" && check.Summary() != tt.summary {
+				t.Errorf("summary - expected %q, got %q", tt.summary, check.Summary())
+			}
+			if tt.perf != "" && check.PerfData() != tt.perf {
+				t.Errorf("perfdata - expected %q, got %q", tt.perf, check.PerfData())
+			}
+		})
+	}
+}
+
+// Provides devices which support no checks
+type unsupportedProvider struct{}
+
+func (up unsupportedProvider) Device(ctx context.Context, p godevman.Dparams) (any, error) {
+	return struct{}{}, nil
+}
+
+func TestPowerGenRun(t *testing.T) {
+	gen := testGenInfo()
+	tests := []struct {
+		name    string
+		args    []string
+		ip      string
+		devices deviceProvider
+		state   int
+		err     bool
+	}{
+		{"common", []string{"-t", "common"}, "192.0.2.1", &fakeDevice{Gen: &gen}, 0, false},
+		{"electrical", []string{"-t", "electrical"}, "192.0.2.1", &fakeDevice{Gen: &gen}, 0, false},
+		{"engine", []string{"-t", "engine"}, "192.0.2.1", &fakeDevice{Gen: &gen}, 0, false},
+		{"unknown type", []string{"-t", "fuel"}, "192.0.2.1", &fakeDevice{Gen: &gen}, 3, true},
+		{"no ip", []string{"-t", "common"}, "", snmpProvider{}, 3, true},
+		{"device error", []string{"-t", "engine"}, "192.0.2.1", &fakeDevice{GenErr: fmt.Errorf("timeout")}, 3, true},
+		{"not supported", []string{"-t", "common"}, "192.0.2.1", unsupportedProvider{}, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := checkParams{
+				checkName: "power_gen",
+				devices:   tt.devices,
+				devParams: godevman.Dparams{Ip: tt.ip},
+			}
+			res := newTestPowerGen(t, tt.args...).Run(context.Background(), p)
+
+			if (res.Err() != nil) != tt.err {
+				t.Errorf("error - expected %v, got %v", tt.err, res.Err())
+			}
+			if res.RetVal() != tt.state {
+				t.Errorf("state - expected %d, got %d", tt.state, res.RetVal())
+			}
+		})
+	}
+}
+
+func TestGenCheckTypes(t *testing.T) {
+	tests := []struct {
+		ctype string
+		exp   []string
+		err   bool
+	}{
+		{"engine", []string{"engine"}, false},
+		{"all", []string{"common", "electrical", "engine"}, false},
+		{"engine, common,engine", []string{"common", "engine"}, false},
+		{"all,engine", []string{"common", "electrical", "engine"}, false},
+		{"", nil, true},
+		{"common,fuel", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := genCheckTypes(tt.ctype)
+		if (err != nil) != tt.err {
+			t.Errorf("%q - expected error %v, got %v", tt.ctype, tt.err, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.exp) {
+			t.Errorf("%q - expected %v, got %v", tt.ctype, tt.exp, got)
+		}
+	}
+}
+
+func TestPowerGenRunSections(t *testing.T) {
+	gen := testGenInfo()
+	gen.FuelLevel = sensor("%", 15, 0)
+	d := &fakeDevice{Gen: &gen}
+	p := checkParams{checkName: "power_gen", devices: d}
+
+	res := newTestPowerGen(t, "-t", "common,engine").Run(context.Background(), p)
+	if res.Err() != nil {
+		t.Fatalf("unexpected error: %v", res.Err())
+	}
+
+	if fmt.Sprint(d.genTargets) != "[[All]]" {
+		t.Errorf("expected one request for all info, got %v", d.genTargets)
+	}
+	if res.RetVal() != 1 {
+		t.Errorf("state - expected 1, got %d", res.RetVal())
+	}
+
+	exp := "Common: OK - Mode: Auto; Breaker: MainsOper; Engine: Ready\n" +
+		"Engine: WARNING - Fuel level: 15%(w); Battery Voltage: 13.6V; Coolant Temperature: 52°C; Fuel Consumption: 0.0l; Running Hours: 61.7h; Number of Starts: 16"
+	if l := res.LongOutput(); l != exp {
+		t.Errorf("long output - expected %q, got %q", exp, l)
+	}
+
+	expPerf := "'Battery Voltage'=13.6V;13:14.5;12:15.5;0; 'Coolant Temperature'=52C;~:98;~:104;; 'Fuel Consumption'=0;;;0; " +
+		"'Fuel level'=15%;20:100;10:100;0;100 'Running Hours'=61.7c;;;0; 'Number of Starts'=16c;;;0;"
+	if pd := res.PerfData(); pd != expPerf {
+		t.Errorf("perfdata - expected %q, got %q", expPerf, pd)
+	}
+
+	// Mains voltage is not reported by il4-30 when all info is requested
+	gen = testGenInfo()
+	gen.MainsVoltL1, gen.MainsVoltL2, gen.MainsVoltL3 = godevman.SensorVal{}, godevman.SensorVal{}, godevman.SensorVal{}
+	res = newTestPowerGen(t, "-t", "all").Run(context.Background(), checkParams{devices: &fakeDevice{Gen: &gen}})
+	if res.Err() != nil || res.RetVal() != 0 {
+		t.Errorf("all - expected OK, got %d (%v)", res.RetVal(), res.Err())
+	}
+}
+
+// Device failing request of all generator info like il4-30 without mains voltage OIDs.
+// Only fields of requested section are returned.
+type sectionDevice struct {
+	fakeDevice
+}
+
+func (d *sectionDevice) Device(ctx context.Context, p godevman.Dparams) (any, error) {
+	return d, nil
+}
+
+func (d *sectionDevice) GeneratorInfo(targets []string) (godevman.GenInfo, error) {
+	i, err := d.fakeDevice.GeneratorInfo(targets)
+	if err != nil {
+		return i, err
+	}
+
+	var out godevman.GenInfo
+	switch targets[0] {
+	case "All":
+		return out, fmt.Errorf("NoSuchName")
+	case "Common":
+		out.GenMode, out.BreakerState, out.EngineState = i.GenMode, i.BreakerState, i.EngineState
+	case "Electrical":
+		out.GenVoltL1, out.GenVoltL2, out.GenVoltL3 = i.GenVoltL1, i.GenVoltL2, i.GenVoltL3
+		out.GenCurrentL1, out.GenCurrentL2, out.GenCurrentL3 = i.GenCurrentL1, i.GenCurrentL2, i.GenCurrentL3
+		out.GenPower, out.GenFreq = i.GenPower, i.GenFreq
+		out.MainsVoltL1 = godevman.SensorVal{Unit: "NotSupported"}
+		out.MainsVoltL2 = godevman.SensorVal{Unit: "NotSupported"}
+		out.MainsVoltL3 = godevman.SensorVal{Unit: "NotSupported"}
+	case "Engine":
+		out.RunHours, out.NumStarts, out.BatteryVolt = i.RunHours, i.NumStarts, i.BatteryVolt
+		out.FuelLevel, out.FuelConsum, out.CoolantTemp = i.FuelLevel, i.FuelConsum, i.CoolantTemp
+	}
+
+	return out, nil
+}
+
+func TestPowerGenRunSectionFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		targets string
+		out     string
+	}{
+		{
+			name:    "types",
+			args:    []string{"-t", "common,electrical"},
+			targets: "[[All] [Common] [Electrical]]",
+			out:     "Mains Voltage L1: NotSupported",
+		},
+		{
+			name:    "frozen",
+			args:    []string{"-t", "common", "-frozen", "1h"},
+			targets: "[[All] [Common] [Electrical] [Engine]]",
+			out:     "Mode: Auto; Breaker: MainsOper; Engine: Ready",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := testGenInfo()
+			d := &sectionDevice{fakeDevice{Gen: &gen}}
+			p := checkParams{devices: d, stateDir: t.TempDir(), devParams: godevman.Dparams{Ip: "192.0.2.1"}}
+
+			res := newTestPowerGen(t, tt.args...).Run(context.Background(), p)
+			if res.Err() != nil {
+				t.Fatalf("unexpected error: %v", res.Err())
+			}
+			if got := fmt.Sprint(d.genTargets); got != tt.targets {
+				t.Errorf("targets - expected %s, got %s", tt.targets, got)
+			}
+			if res.RetVal() != 0 {
+				t.Errorf("state - expected 0, got %d", res.RetVal())
+			}
+			if o := res.Output(); !strings.Contains(o, tt.out) {
+				t.Errorf("output - expected to contain %q, got %q", tt.out, o)
+			}
+		})
+	}
+
+	// Section errors are reported
+	gen := testGenInfo()
+	d := &sectionDevice{fakeDevice{Gen: &gen, GenErr: fmt.Errorf("timeout")}}
+	res := newTestPowerGen(t, "-t", "all").Run(context.Background(), checkParams{devices: d})
+	if res.Err() == nil || fmt.Sprint(d.genTargets) != "[[All] [Common]]" {
+		t.Errorf("expected error after first section, got %v (%v)", res.Err(), d.genTargets)
+	}
+}
@@ -2,82 +2,86 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
-	"net"
-	"os"
 	"sort"
 	"strings"
 
 	"github.com/aretaja/godevman"
-	"github.com/aretaja/icingahelper"
 	"github.com/kr/pretty"
 )
 
+func init() {
+	registerCheck(&checkSyncro{})
+}
+
 // Adds syncro check functionality to checkParams type
 type checkSyncro struct {
 	checkParams
 }
 
-func (c *checkSyncro) state() {
-	c.initSubParams()
+func (c *checkSyncro) Name() string {
+	return "sync_state"
+}
+
+func (c *checkSyncro) Info() []string {
+	return []string{"Syncronisation state check (Freq and Phase sync).",
+		"\tCRITICAL - fsync signal not locked or psync not phase aligned.",
+		"\tWARNING - sync source quality is bad.",
+		"\tProvides long output and no performance data."}
+}
 
-	// Initialize new check object
-	check := icingahelper.NewCheck("SYNC")
+// No check specific arguments
+func (c *checkSyncro) SetFlags(flag *flag.FlagSet) {}
 
-	// Exit if no host ip submitted
-	if net.ParseIP(c.devParams.Ip) == nil {
-		log.Println("error: valid host ip is required")
-		os.Exit(check.RetVal())
-	}
+func (c *checkSyncro) Run(ctx context.Context, p checkParams) *checkResult {
+	c.checkParams = p
 
-	md := c.initDevice()
+	// Initialize new check result
+	check := newResult("SYNC")
+
+	md, err := c.initDevice(ctx)
+	if err != nil {
+		return check.Fail(err)
+	}
 
 	fd, ok := md.(godevman.DevFreqSyncReader)
 	if !ok {
-		log.Println("error: freq sync state check is not supported on this device type")
-		os.Exit(check.RetVal())
+		return check.Fail(fmt.Errorf("freq sync state check is not supported on this device type"))
 	}
 
 	pd, ok := md.(godevman.DevPhaseSyncReader)
 	if !ok {
-		log.Println("error: phase sync state check is not supported on this device type")
-		os.Exit(check.RetVal())
+		return check.Fail(fmt.Errorf("phase sync state check is not supported on this device type"))
 	}
 
 	// Get freq sync information from device
-	resf, err := fd.FreqSyncInfo()
+	resf, err := fetch(ctx, &c.checkParams, "freq sync info", fd.FreqSyncInfo)
 	if err != nil {
-		log.Printf("error: FreqSyncInfo: %v", err)
-		l := check.RetVal()
 		if strings.HasSuffix(err.Error(), "not configured") {
-			l = 0
+			logs.Infof("freq sync is not configured on device")
+			check.SetRetVal(0)
 		}
-		os.Exit(l)
+		return check.Fail(fmt.Errorf("FreqSyncInfo: %v", err))
 	}
 	// DEBUG
-	if c.dbg {
-		fmt.Printf("freq sync info: %# v\n", pretty.Formatter(resf))
-	}
+	logs.Debugf("freq sync info: %# v", pretty.Formatter(resf))
 
 	// Get phase sync information from device
-	resp, err := pd.PhaseSyncInfo()
+	resp, err := fetch(ctx, &c.checkParams, "phase sync info", pd.PhaseSyncInfo)
 	if err != nil {
-		log.Printf("error: PhaseSyncInfo: %v", err)
-		l := check.RetVal()
 		if strings.HasSuffix(err.Error(), "not configured") {
-			l = 0
+			logs.Infof("phase sync is not configured on device")
+			check.SetRetVal(0)
 		}
-		os.Exit(l)
+		return check.Fail(fmt.Errorf("PhaseSyncInfo: %v", err))
 	}
 	// DEBUG
-	if c.dbg {
-		fmt.Printf("phase sync info: %# v\n", pretty.Formatter(resp))
-	}
+	logs.Debugf("phase sync info: %# v", pretty.Formatter(resp))
 
 	check.SetRetVal(0)
-	// Freq sync data to icingahelper
+	// Freq sync data to check result
 	fl := ""
 	if resf.SrcsQaLevel != nil {
 		p := resf.SrcsQaLevel
@@ -129,7 +133,7 @@ func (c *checkSyncro) state() {
 		check.AddMsg(3, "Fsync Qa: Na", "")
 	}
 
-	// Phase sync data to icingahelper
+	// Phase sync data to check result
 	pm := []string{}
 
 	if resp.SrcsState != nil {
@@ -203,22 +207,5 @@ func (c *checkSyncro) state() {
 		check.AddMsg(0, fmt.Sprintf("GrandMaster: %s", resp.ParentGmIdent.Value), "")
 	}
 
-	fmt.Print(check.FinalMsg())
-	os.Exit(check.RetVal())
-}
-
-func (c *checkSyncro) initSubParams() {
-	flag := flag.NewFlagSet("sync_state", flag.ExitOnError)
-	var info = flag.Bool("info", false, "About check")
-	flag.Parse(c.subArgs)
-
-	// Show info about check
-	if *info {
-		if i, ok := checksInfo[c.checkName]; ok {
-			fmt.Printf("%s: %s\n", c.checkName, strings.Join(i, "\n"))
-		} else {
-			fmt.Printf("%s: no additional information\n", c.checkName)
-		}
-		os.Exit(3)
-	}
+	return check
 }
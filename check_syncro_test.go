@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aretaja/godevman"
+)
+
+// Returns device with locked and phase aligned sync
+func testSyncDevice() *fakeDevice {
+	return &fakeDevice{
+		FreqSync: &godevman.FreqSyncInfo{
+			SrcsQaLevel: map[string]string{
+				"2(GigabitEthernet0/3/6)": "PRC",
+				"1(Internal)":             "SEC",
+			},
+			ClockMode:    valStr("locked"),
+			ClockQaLevel: valStr("PRC"),
+		},
+		PhaseSync: &godevman.PhaseSyncInfo{
+			SrcsState: map[string]string{
+				"1(SRC-DESCR1)": "slave",
+			},
+			State:         valStr("phaseAligned"),
+			ParentGmClass: valStr("prtcLock(6)"),
+			ParentGmIdent: valStr("0xBE:EF:1:FF:FE:0:2:30"),
+			HopsToGm:      godevman.ValU64{Value: 1, IsSet: true},
+		},
+	}
+}
+
+func TestSyncroRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(d *fakeDevice)
+		state   int
+		summary string
+		err     bool
+	}{
+		{
+			name:    "ok",
+			modify:  func(d *fakeDevice) {},
+			state:   0,
+			summary: "Fsync Mode: locked; Fsync Qa: PRC; PTP Mode: phaseAligned; PTP GM Class: prtcLock(6); GrandMaster: 0xBE:EF:1:FF:FE:0:2:30",
+		},
+		{
+			name:    "not locked",
+			modify:  func(d *fakeDevice) { d.FreqSync.ClockMode = valStr("freerun") },
+			state:   2,
+			summary: "Fsync Mode: freerun(c); Fsync Qa: PRC; PTP Mode: phaseAligned; PTP GM Class: prtcLock(6); GrandMaster: 0xBE:EF:1:FF:FE:0:2:30",
+		},
+		{
+			name:   "bad quality",
+			modify: func(d *fakeDevice) { d.FreqSync.ClockQaLevel = valStr("SEC") },
+			state:  1,
+		},
+		{
+			name:   "not phase aligned",
+			modify: func(d *fakeDevice) { d.PhaseSync.State = valStr("acquiring") },
+			state:  2,
+		},
+		{
+			name:   "gm holdover",
+			modify: func(d *fakeDevice) { d.PhaseSync.ParentGmClass = valStr("holdover(7)") },
+			state:  2,
+		},
+		{
+			name:   "gm degraded",
+			modify: func(d *fakeDevice) { d.PhaseSync.ParentGmClass = valStr("freerun(248)") },
+			state:  1,
+		},
+		{
+			name:   "fsync not configured",
+			modify: func(d *fakeDevice) { d.FreqErr = fmt.Errorf("freq sync not configured") },
+			state:  0,
+			err:    true,
+		},
+		{
+			name:   "psync error",
+			modify: func(d *fakeDevice) { d.PhaseErr = fmt.Errorf("snmp timeout") },
+			state:  3,
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testSyncDevice()
+			tt.modify(d)
+			p := checkParams{
+				checkName: "sync_state",
+				devices:   d,
+				devParams: godevman.Dparams{Ip: "192.0.2.1"},
+			}
+			res := (&checkSyncro{}).Run(context.Background(), p)
+
+			if (res.Err() != nil) != tt.err {
+				t.Errorf("error - expected %v, got %v", tt.err, res.Err())
+			}
+			if res.RetVal() != tt.state {
+				t.Errorf("state - expected %d, got %d (%s)", tt.state, res.RetVal(), res.Summary())
+			}
+			if tt.summary != "" && res.Summary() != tt.summary {
+				t.Errorf("summary - expected %q, got %q", tt.summary, res.Summary())
+			}
+		})
+	}
+}
+
+func TestSyncroLongOutput(t *testing.T) {
+	p := checkParams{
+		checkName: "sync_state",
+		devices:   testSyncDevice(),
+		devParams: godevman.Dparams{Ip: "192.0.2.1"},
+	}
+	res := (&checkSyncro{}).Run(context.Background(), p)
+
+	exp := "Configured frequency sync sources:\n 1(Internal): SEC\n 2(GigabitEthernet0/3/6): PRC\nFreq sync (ok)\n" +
+		"Configured phase sync sources:\n 1(SRC-DESCR1): slave\nHops to GM: 1\nPhase sync (ok)"
+	if l := res.LongOutput(); l != exp {
+		t.Errorf("long output - expected %q, got %q", exp, l)
+	}
+}
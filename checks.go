@@ -0,0 +1,45 @@
+// Check interface and registry of available checks
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+)
+
+// Check is implemented by every check provided by plugin
+type Check interface {
+	// Name of check used as <check_name> on command line
+	Name() string
+	// Description of check. First line is short description
+	Info() []string
+	// Define check specific arguments
+	SetFlags(fs *flag.FlagSet)
+	// Run check using common parameters. Check specific arguments are parsed already.
+	// Must not print plugin output or exit
+	Run(ctx context.Context, p checkParams) *checkResult
+}
+
+// registered checks
+var checks = map[string]Check{}
+
+// Register check. Must be called from init() of file which implements the check
+func registerCheck(c Check) {
+	n := c.Name()
+	if _, ok := checks[n]; ok {
+		panic(fmt.Sprintf("check %s already registered", n))
+	}
+	checks[n] = c
+}
+
+// Returns sorted names of registered checks
+func checkNames() []string {
+	keys := make([]string, 0, len(checks))
+	for k := range checks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,106 @@
+// Configuration file with named credential profiles
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default configuration file path. Can be overridden by -config flag or CHECK_GODEVMAN_CONFIG env variable
+const defaultConfigPath = "/etc/check-godevman-multi/config.yaml"
+
+// Configuration file content
+type config struct {
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// Named set of SNMP settings. Empty values are not used
+type profile struct {
+	User     string `yaml:"user"`       // -u
+	Prot     string `yaml:"auth_proto"` // -a
+	Pass     string `yaml:"auth_pass"`  // -A
+	Slevel   string `yaml:"sec_level"`  // -l
+	PrivProt string `yaml:"priv_proto"` // -x
+	PrivPass string `yaml:"priv_pass"`  // -X
+	Ver      int    `yaml:"version"`    // -V
+}
+
+// Returns configuration file path. Flag value is preferred over env variable
+func configPath(flagVal string) string {
+	if flagVal != "" {
+		return flagVal
+	}
+	if p, ok := os.LookupEnv("CHECK_GODEVMAN_CONFIG"); ok && p != "" {
+		return p
+	}
+
+	return defaultConfigPath
+}
+
+// Load configuration file. File with secrets must not be accessible by others
+func loadConfig(path string) (config, error) {
+	var cfg config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("read config: %v", err)
+	}
+
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse config %s: %v", path, err)
+	}
+
+	if cfg.hasSecrets() {
+		if err := checkPrivate(path, "config file"); err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// Does some profile contain secrets. Besides passwords SNMP v1/v2c user is community
+func (cfg config) hasSecrets() bool {
+	for _, pr := range cfg.Profiles {
+		if pr.Pass != "" || pr.PrivPass != "" || pr.User != "" && pr.Ver != 3 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply named profile to check params.
+// Values of flags which are explicitly set on command line are not overridden.
+func (cfg config) applyProfile(params *checkParams, name string, setFlags map[string]bool) error {
+	pr, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %s not found in config", name)
+	}
+
+	c := &params.devParams.SnmpCred
+	if pr.Ver != 0 && !setFlags["V"] {
+		c.Ver = pr.Ver
+	}
+	if pr.User != "" && !setFlags["u"] {
+		c.User = pr.User
+	}
+	if pr.Prot != "" && !setFlags["a"] {
+		c.Prot = pr.Prot
+	}
+	if pr.Pass != "" && !setFlags["A"] {
+		c.Pass = pr.Pass
+	}
+	if pr.Slevel != "" && !setFlags["l"] {
+		c.Slevel = pr.Slevel
+	}
+	if pr.PrivProt != "" && !setFlags["x"] {
+		c.PrivProt = pr.PrivProt
+	}
+	if pr.PrivPass != "" && !setFlags["X"] {
+		c.PrivPass = pr.PrivPass
+	}
+
+	return nil
+}
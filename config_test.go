@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestApplyProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `profiles:
+  genset-v2:
+    version: 3
+    user: monitor
+    auth_proto: SHA
+    auth_pass: authsecret
+    sec_level: authPriv
+    priv_proto: AES
+    priv_pass: privsecret
+  core-routers:
+    user: community
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	defaults := godevman.SnmpCred{Ver: 2, User: "public", Prot: "MD5", Slevel: "authPriv", PrivProt: "DES"}
+	tests := []struct {
+		name     string
+		profile  string
+		setFlags map[string]bool
+		exp      godevman.SnmpCred
+		err      bool
+	}{
+		{
+			name:    "full profile",
+			profile: "genset-v2",
+			exp: godevman.SnmpCred{Ver: 3, User: "monitor", Prot: "SHA", Pass: "authsecret", Slevel: "authPriv",
+				PrivProt: "AES", PrivPass: "privsecret"},
+		},
+		{
+			name:     "flags override profile",
+			profile:  "genset-v2",
+			setFlags: map[string]bool{"u": true, "X": true},
+			exp: godevman.SnmpCred{Ver: 3, User: "public", Prot: "SHA", Pass: "authsecret", Slevel: "authPriv",
+				PrivProt: "AES"},
+		},
+		{
+			name:    "partial profile keeps defaults",
+			profile: "core-routers",
+			exp:     godevman.SnmpCred{Ver: 2, User: "community", Prot: "MD5", Slevel: "authPriv", PrivProt: "DES"},
+		},
+		{
+			name:    "missing profile",
+			profile: "none",
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := checkParams{devParams: godevman.Dparams{SnmpCred: defaults}}
+			err := cfg.applyProfile(&p, tt.profile, tt.setFlags)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.devParams.SnmpCred != tt.exp {
+				t.Errorf("expected %+v, got %+v", tt.exp, p.devParams.SnmpCred)
+			}
+		})
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("CHECK_GODEVMAN_CONFIG", "/tmp/env.yaml")
+	if p := configPath("/tmp/flag.yaml"); p != "/tmp/flag.yaml" {
+		t.Errorf("expected flag value, got %s", p)
+	}
+	if p := configPath(""); p != "/tmp/env.yaml" {
+		t.Errorf("expected env value, got %s", p)
+	}
+}
+
+func TestLoadConfigMode(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		data string
+		mode os.FileMode
+		err  bool
+	}{
+		{"private with passwords", "profiles:\n  v3:\n    version: 3\n    user: monitor\n    auth_pass: secret\n", 0o600, false},
+		{"open with passwords", "profiles:\n  v3:\n    version: 3\n    user: monitor\n    auth_pass: secret\n", 0o644, true},
+		{"open with community", "profiles:\n  v2:\n    user: community\n", 0o644, true},
+		{"open without secrets", "profiles:\n  v3:\n    version: 3\n    user: monitor\n    auth_proto: SHA\n", 0o644, false},
+	}
+
+	for n, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("config%d.yaml", n))
+			if err := os.WriteFile(path, []byte(tt.data), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := loadConfig(path)
+			if tt.err && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
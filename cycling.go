@@ -0,0 +1,50 @@
+// Short-cycling detection from running hours and number of starts history
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+// Running hours and number of starts sample
+type cycleSample struct {
+	Time      time.Time `json:"time"`
+	RunHours  float64   `json:"run_hours"`
+	NumStarts uint64    `json:"num_starts"`
+}
+
+// Evaluate average run time per start within cycling window.
+// Evaluated only if there are at least minimal number of starts in window.
+// History is restarted if running hours or number of starts decrease.
+func (c *checkPowerGen) shortCycling(check *checkResult, i godevman.GenInfo) {
+	if !i.RunHours.IsSet || !i.NumStarts.IsSet {
+		return
+	}
+
+	t := now()
+	cur := cycleSample{Time: t, RunHours: sensorValue(i.RunHours), NumStarts: i.NumStarts.Value}
+	hist := c.state.Cycles
+	if n := len(hist); n > 0 && (cur.RunHours < hist[n-1].RunHours || cur.NumStarts < hist[n-1].NumStarts) {
+		hist = nil
+	}
+	hist = append(hist, cur)
+
+	hist = trimHistory(hist, t.Add(-c.subParams.cycleWindow), func(s cycleSample) time.Time { return s.Time })
+	c.state.Cycles = hist
+
+	first := hist[0]
+	starts := cur.NumStarts - first.NumStarts
+	check.AddPerf(newPerf("Starts in Window", float64(starts)).Min(0))
+	if starts == 0 || starts < c.subParams.cycleStarts {
+		return
+	}
+
+	avg := round((cur.RunHours-first.RunHours)*60/float64(starts), 1)
+	w, cr := c.subParams.wRunTime, c.subParams.cRunTime
+	l := check.AlarmLevel(avg, w, cr)
+	check.AddMsg(l, fmt.Sprintf("Avg Run per Start: %.1fmin", avg),
+		fmt.Sprintf("%d starts with %.1fh running time since %s", starts, cur.RunHours-first.RunHours, first.Time.Format("2006-01-02 15:04")))
+	check.AddPerf(newPerf("Avg Run per Start", avg).Thresholds(w, cr).Min(0))
+}
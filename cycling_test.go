@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestPowerGenShortCycling(t *testing.T) {
+	dir := t.TempDir()
+	runs := []struct {
+		time   time.Time
+		hours  uint64
+		starts uint64
+		state  int
+		msg    string
+		perf   string
+	}{
+		{time.Date(2023, 5, 1, 9, 0, 0, 0, time.Local), 617, 16, 0, "", "'Starts in Window'=0;;;0;"},
+		{time.Date(2023, 5, 1, 10, 0, 0, 0, time.Local), 618, 18, 0, "", "'Starts in Window'=2;;;0;"},
+		{time.Date(2023, 5, 1, 11, 0, 0, 0, time.Local), 618, 19, 1, "Avg Run per Start: 2.0min(w)", "'Avg Run per Start'=2;15:;;0;"},
+		{time.Date(2023, 5, 2, 12, 0, 0, 0, time.Local), 648, 22, 0, "Avg Run per Start: 60.0min", "'Starts in Window'=3;;;0;"},
+		{time.Date(2023, 5, 2, 12, 5, 0, 0, time.Local), 648, 2, 0, "", "'Starts in Window'=0;;;0;"},
+	}
+
+	for _, r := range runs {
+		i := testGenInfo()
+		i.RunHours = sensor("h", r.hours, 10)
+		i.NumStarts = godevman.ValU64{Value: r.starts, IsSet: true}
+		res := runStateful(t, dir, r.time, i, "-t", "engine", "-detect-cycling")
+
+		if res.RetVal() != r.state {
+			t.Errorf("%s - expected state %d, got %d (%s)", r.time, r.state, res.RetVal(), res.Summary())
+		}
+		if r.msg != "" && !strings.Contains(res.Summary(), r.msg) {
+			t.Errorf("%s - expected %q in %q", r.time, r.msg, res.Summary())
+		}
+		if r.msg == "" && strings.Contains(res.Summary(), "Avg Run per Start") {
+			t.Errorf("%s - unexpected run time message in %q", r.time, res.Summary())
+		}
+		if !strings.Contains(res.PerfData(), r.perf) {
+			t.Errorf("%s - expected %q in %q", r.time, r.perf, res.PerfData())
+		}
+	}
+}
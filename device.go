@@ -0,0 +1,246 @@
+// Device providers used by checks
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aretaja/godevman"
+	"github.com/kr/pretty"
+)
+
+// Provides device objects for checks
+type deviceProvider interface {
+	// Returns morphed godevman device or object which implements the same reader interfaces
+	Device(ctx context.Context, p godevman.Dparams) (any, error)
+}
+
+// Provides real devices using godevman
+type snmpProvider struct {
+	// Address family used in host name resolution. "ip4", "ip6" or "ip"
+	network string
+}
+
+func (sp snmpProvider) Device(ctx context.Context, p godevman.Dparams) (any, error) {
+	network := sp.network
+	if network == "" {
+		network = "ip"
+	}
+
+	ip, err := resolveHost(ctx, p.Ip, network)
+	if err != nil {
+		return nil, err
+	}
+	p.Ip = ip
+
+	device, err := godevman.NewDevice(&p)
+	if err != nil {
+		return nil, fmt.Errorf("godevman.NewDevice: %v", err)
+	}
+
+	md := device.Morph()
+	// DEBUG
+	logs.Debugf("godevman morphed device: %# v", pretty.Formatter(md))
+
+	return md, nil
+}
+
+// In-memory device. Returns preloaded data instead of polling real device.
+// Nil data or non nil error makes corresponding reader method fail.
+// Serialized form is used as snapshot in record and replay mode.
+type fakeDevice struct {
+	GenErr    error                   `json:"-"`
+	FreqErr   error                   `json:"-"`
+	PhaseErr  error                   `json:"-"`
+	Gen       *godevman.GenInfo       `json:"gen_info,omitempty"`
+	FreqSync  *godevman.FreqSyncInfo  `json:"freq_sync_info,omitempty"`
+	PhaseSync *godevman.PhaseSyncInfo `json:"phase_sync_info,omitempty"`
+	// Targets of GeneratorInfo calls
+	genTargets [][]string
+}
+
+// Load device snapshot from JSON file
+func loadSnapshot(path string) (*fakeDevice, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %v", err)
+	}
+
+	var fd fakeDevice
+	if err := json.Unmarshal(b, &fd); err != nil {
+		return nil, fmt.Errorf("parse snapshot %s: %v", path, err)
+	}
+
+	return &fd, nil
+}
+
+// Save device snapshot to JSON file
+func (fd *fakeDevice) save(path string) error {
+	b, err := json.MarshalIndent(fd, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %v", err)
+	}
+
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write snapshot: %v", err)
+	}
+
+	return nil
+}
+
+// Fake device is its own provider
+func (fd *fakeDevice) Device(ctx context.Context, p godevman.Dparams) (any, error) {
+	return fd, nil
+}
+
+func (fd *fakeDevice) GeneratorInfo(targets []string) (godevman.GenInfo, error) {
+	fd.genTargets = append(fd.genTargets, targets)
+	if fd.GenErr != nil {
+		return godevman.GenInfo{}, fd.GenErr
+	}
+	if fd.Gen == nil {
+		return godevman.GenInfo{}, fmt.Errorf("no generator info")
+	}
+
+	return *fd.Gen, nil
+}
+
+func (fd *fakeDevice) FreqSyncInfo() (*godevman.FreqSyncInfo, error) {
+	if fd.FreqErr != nil {
+		return nil, fd.FreqErr
+	}
+	if fd.FreqSync == nil {
+		return nil, fmt.Errorf("no freq sync info")
+	}
+
+	return fd.FreqSync, nil
+}
+
+func (fd *fakeDevice) PhaseSyncInfo() (*godevman.PhaseSyncInfo, error) {
+	if fd.PhaseErr != nil {
+		return nil, fd.PhaseErr
+	}
+	if fd.PhaseSync == nil {
+		return nil, fmt.Errorf("no phase sync info")
+	}
+
+	return fd.PhaseSync, nil
+}
+
+// Provides devices which record all responses to snapshot file
+type recordProvider struct {
+	base deviceProvider
+	path string
+}
+
+func (rp recordProvider) Device(ctx context.Context, p godevman.Dparams) (any, error) {
+	md, err := rp.base.Device(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordDevice{dev: md, path: rp.path}, nil
+}
+
+// Wraps device and saves every successful response to snapshot file
+type recordDevice struct {
+	dev  any
+	path string
+	snap fakeDevice
+}
+
+func (rd *recordDevice) GeneratorInfo(targets []string) (godevman.GenInfo, error) {
+	d, ok := rd.dev.(godevman.DevGenReader)
+	if !ok {
+		return godevman.GenInfo{}, fmt.Errorf("generator info is not supported on this device type")
+	}
+
+	res, err := d.GeneratorInfo(targets)
+	if err != nil {
+		return res, err
+	}
+
+	rd.snap.Gen = &res
+
+	return res, rd.snap.save(rd.path)
+}
+
+func (rd *recordDevice) FreqSyncInfo() (*godevman.FreqSyncInfo, error) {
+	d, ok := rd.dev.(godevman.DevFreqSyncReader)
+	if !ok {
+		return nil, fmt.Errorf("freq sync info is not supported on this device type")
+	}
+
+	res, err := d.FreqSyncInfo()
+	if err != nil {
+		return res, err
+	}
+
+	rd.snap.FreqSync = res
+
+	return res, rd.snap.save(rd.path)
+}
+
+func (rd *recordDevice) PhaseSyncInfo() (*godevman.PhaseSyncInfo, error) {
+	d, ok := rd.dev.(godevman.DevPhaseSyncReader)
+	if !ok {
+		return nil, fmt.Errorf("phase sync info is not supported on this device type")
+	}
+
+	res, err := d.PhaseSyncInfo()
+	if err != nil {
+		return res, err
+	}
+
+	rd.snap.PhaseSync = res
+
+	return res, rd.snap.save(rd.path)
+}
+
+// Fetch data from device using f. Gives up when context is done.
+// Failed fetches are retried p.retries times with doubling backoff.
+func fetch[T any](ctx context.Context, p *checkParams, what string, f func() (T, error)) (T, error) {
+	type result struct {
+		val T
+		err error
+	}
+
+	var zero T
+	backoff := p.backoff
+	for attempt := 0; ; attempt++ {
+		ch := make(chan result, 1)
+		go func() {
+			v, err := f()
+			ch <- result{v, err}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return zero, ctxError(ctx, p, what)
+		case r := <-ch:
+			if r.err == nil || attempt >= p.retries {
+				return r.val, r.err
+			}
+			logs.Warnf("fetch %s failed (attempt %d), retry in %s: %v", what, attempt+1, backoff, r.err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return zero, ctxError(ctx, p, what)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Returns error describing why context is done
+func ctxError(ctx context.Context, p *checkParams, what string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timeout after %s while fetching %s", p.timeout, what)
+	}
+
+	return fmt.Errorf("%v while fetching %s", ctx.Err(), what)
+}
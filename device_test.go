@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	gen := testGenInfo()
+	src := testSyncDevice()
+	src.Gen = &gen
+
+	md, err := recordProvider{base: src, path: path}.Device(context.Background(), godevman.Dparams{})
+	if err != nil {
+		t.Fatalf("record device: %v", err)
+	}
+
+	rd := md.(*recordDevice)
+	if _, err := rd.GeneratorInfo([]string{"All"}); err != nil {
+		t.Fatalf("record GeneratorInfo: %v", err)
+	}
+	if _, err := rd.FreqSyncInfo(); err != nil {
+		t.Fatalf("record FreqSyncInfo: %v", err)
+	}
+	if _, err := rd.PhaseSyncInfo(); err != nil {
+		t.Fatalf("record PhaseSyncInfo: %v", err)
+	}
+
+	fd, err := loadSnapshot(path)
+	if err != nil {
+		t.Fatalf("load snapshot: %v", err)
+	}
+
+	if !reflect.DeepEqual(fd.Gen, src.Gen) {
+		t.Errorf("gen info - expected %v, got %v", src.Gen, fd.Gen)
+	}
+	if !reflect.DeepEqual(fd.FreqSync, src.FreqSync) {
+		t.Errorf("freq sync info - expected %v, got %v", src.FreqSync, fd.FreqSync)
+	}
+	if !reflect.DeepEqual(fd.PhaseSync, src.PhaseSync) {
+		t.Errorf("phase sync info - expected %v, got %v", src.PhaseSync, fd.PhaseSync)
+	}
+
+	// Replayed snapshot gives the same verdict as original device
+	p := checkParams{checkName: "sync_state", devices: fd}
+	if res := (&checkSyncro{}).Run(context.Background(), p); res.Err() != nil || res.RetVal() != 0 {
+		t.Errorf("replay - expected OK, got %d (%v)", res.RetVal(), res.Err())
+	}
+}
+
+func TestLoadSnapshotMissing(t *testing.T) {
+	if _, err := loadSnapshot(filepath.Join(t.TempDir(), "none.json")); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	defer func(l *logger) { logs = l }(logs)
+	logs = newLogger(io.Discard, levelError)
+
+	t.Run("retry", func(t *testing.T) {
+		p := checkParams{retries: 2, backoff: time.Millisecond}
+		calls := 0
+		v, err := fetch(context.Background(), &p, "test data", func() (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, fmt.Errorf("request timeout")
+			}
+			return 42, nil
+		})
+		if err != nil || v != 42 || calls != 3 {
+			t.Errorf("expected 42 after 3 calls, got %d after %d calls (%v)", v, calls, err)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		p := checkParams{retries: 1, backoff: time.Millisecond}
+		calls := 0
+		_, err := fetch(context.Background(), &p, "test data", func() (int, error) {
+			calls++
+			return 0, fmt.Errorf("request timeout")
+		})
+		if err == nil || calls != 2 {
+			t.Errorf("expected error after 2 calls, got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		p := checkParams{timeout: 10 * time.Millisecond}
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+
+		_, err := fetch(ctx, &p, "generator info", func() (int, error) {
+			time.Sleep(time.Second)
+			return 0, nil
+		})
+		exp := "timeout after 10ms while fetching generator info"
+		if err == nil || err.Error() != exp {
+			t.Errorf("expected %q, got %v", exp, err)
+		}
+	})
+}
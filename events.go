@@ -0,0 +1,36 @@
+// Alarm events held between plugin runs
+package main
+
+import "time"
+
+// Alarm event detected by comparing with previous check. Event is kept active for event hold
+// period, so it lasts several check attempts and can become hard state in monitoring system.
+type heldEvent struct {
+	Level int       `json:"level"`
+	Value float64   `json:"value"` // event size, fe. number of starts
+	Since time.Time `json:"since"` // start of event period
+	Until time.Time `json:"until"` // end of hold period
+}
+
+// Returns event held by earlier check by name. Expired event is removed
+func (c *checkPowerGen) activeEvent(name string) (heldEvent, bool) {
+	e, ok := c.state.Events[name]
+	if ok && now().After(e.Until) {
+		delete(c.state.Events, name)
+		return heldEvent{}, false
+	}
+
+	return e, ok
+}
+
+// Hold event for event hold period from now. Returns held event.
+// Event is reported by current check also if hold period is 0.
+func (c *checkPowerGen) holdEvent(name string, e heldEvent) heldEvent {
+	if c.state.Events == nil {
+		c.state.Events = map[string]heldEvent{}
+	}
+	e.Until = now().Add(c.subParams.eventHold)
+	c.state.Events[name] = e
+
+	return e
+}
@@ -0,0 +1,92 @@
+// Frozen telemetry detection
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+// Telemetry fingerprint of fetched generator info
+type telemetryState struct {
+	Fingerprint string    `json:"fingerprint"`
+	Since       time.Time `json:"since"` // time when fingerprint changed last
+}
+
+// Alarm state of frozen telemetry. Implements flag.Value. Valid values are warning and unknown
+type frozenState int
+
+func (s *frozenState) Set(v string) error {
+	switch v {
+	case "warning":
+		*s = 1
+	case "unknown":
+		*s = 3
+	default:
+		return fmt.Errorf("not valid frozen telemetry state - %s: expected warning|unknown", v)
+	}
+
+	return nil
+}
+
+func (s *frozenState) String() string {
+	if s != nil && *s == 1 {
+		return "warning"
+	}
+
+	return "unknown"
+}
+
+// Returns fingerprint of all generator info values
+func fingerprint(i godevman.GenInfo) (string, error) {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return "", fmt.Errorf("telemetry fingerprint: %v", err)
+	}
+	h := fnv.New64a()
+	h.Write(b)
+
+	return fmt.Sprintf("%016x", h.Sum64()), nil
+}
+
+// Should telemetry values change between runs. Running hours of running engine
+// and measured AC voltages never stay identical for long on working controller.
+func liveTelemetry(i godevman.GenInfo) bool {
+	if i.EngineState.IsSet && inList(runningEngineStates, i.EngineState.Value) {
+		return true
+	}
+	for _, v := range []godevman.SensorVal{i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3, i.GenVoltL1, i.GenVoltL2, i.GenVoltL3} {
+		if v.IsSet && v.Value != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Alarm if all values of live telemetry have been identical for frozen period
+func (c *checkPowerGen) frozenTelemetry(check *checkResult, i godevman.GenInfo) error {
+	fp, err := fingerprint(i)
+	if err != nil {
+		return err
+	}
+
+	t := now()
+	ts := c.state.Telemetry
+	if ts == nil || ts.Fingerprint != fp || !liveTelemetry(i) {
+		ts = &telemetryState{Fingerprint: fp, Since: t}
+	}
+	c.state.Telemetry = ts
+
+	if d := t.Sub(ts.Since); d >= c.subParams.frozen {
+		l := int(c.subParams.frozenState)
+		check.Raise(l)
+		check.AddMsg(l, fmt.Sprintf("Telemetry Frozen: %.1fh", d.Hours()),
+			fmt.Sprintf("All values unchanged since %s", ts.Since.Format("2006-01-02 15:04")))
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestFrozenState(t *testing.T) {
+	var s frozenState
+	if err := s.Set("warning"); err != nil || s != 1 || s.String() != "warning" {
+		t.Errorf("expected warning, got %d, %v", s, err)
+	}
+	if err := s.Set("unknown"); err != nil || s != 3 || s.String() != "unknown" {
+		t.Errorf("expected unknown, got %d, %v", s, err)
+	}
+	if err := s.Set("critical"); err == nil {
+		t.Errorf("expected error on critical")
+	}
+}
+
+func TestLiveTelemetry(t *testing.T) {
+	i := testGenInfo()
+	if !liveTelemetry(i) {
+		t.Errorf("expected live telemetry with mains voltage")
+	}
+
+	i.MainsVoltL1 = sensor("V", 0, 0)
+	i.MainsVoltL2 = sensor("V", 0, 0)
+	i.MainsVoltL3 = sensor("V", 0, 0)
+	if liveTelemetry(i) {
+		t.Errorf("expected no live telemetry without voltages and running engine")
+	}
+
+	i.EngineState = valStr("Running")
+	if !liveTelemetry(i) {
+		t.Errorf("expected live telemetry with running engine")
+	}
+}
+
+func TestPowerGenFrozenTelemetry(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2023, 5, 1, 9, 0, 0, 0, time.Local)
+	runs := []struct {
+		min     int
+		battery uint64
+		args    []string
+		state   int
+		msg     string
+	}{
+		{0, 136, nil, 0, ""},
+		{60, 136, nil, 0, ""},
+		{120, 136, nil, 3, "Telemetry Frozen: 2.0h(u)"},
+		{150, 136, []string{"-frozen-state", "warning"}, 1, "Telemetry Frozen: 2.5h(w)"},
+		{155, 135, nil, 0, ""},
+	}
+
+	for _, r := range runs {
+		i := testGenInfo()
+		i.BatteryVolt = sensor("V", r.battery, 10)
+		res := runStateful(t, dir, start.Add(time.Duration(r.min)*time.Minute), i, append([]string{"-t", "engine", "-frozen", "2h"}, r.args...)...)
+
+		if res.RetVal() != r.state {
+			t.Errorf("%d min - expected state %d, got %d (%s)", r.min, r.state, res.RetVal(), res.Summary())
+		}
+		if r.msg != "" && !strings.Contains(res.Summary(), r.msg) {
+			t.Errorf("%d min - expected %q in %q", r.min, r.msg, res.Summary())
+		}
+		if r.msg == "" && strings.Contains(res.Summary(), "Telemetry Frozen") {
+			t.Errorf("%d min - unexpected frozen message in %q", r.min, res.Summary())
+		}
+	}
+
+	// Engine state and voltages are needed also for single check type
+	i := testGenInfo()
+	d := &fakeDevice{Gen: &i}
+	newTestPowerGen(t, "-t", "engine", "-frozen", "2h").Run(context.Background(), checkParams{devices: d, stateDir: dir, devParams: godevman.Dparams{Ip: "192.0.2.1"}})
+	if len(d.genTargets) != 1 || d.genTargets[0][0] != "All" {
+		t.Errorf("expected All target, got %v", d.genTargets)
+	}
+}
@@ -0,0 +1,61 @@
+// Fuel autonomy estimation of running generator
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+// Minimal fuel level history length for consumption rate estimation
+const minFuelSpan = 15 * time.Minute
+
+// Fuel level sample
+type fuelSample struct {
+	Time  time.Time `json:"time"`
+	Level float64   `json:"level"` // fuel level in percents
+}
+
+// Evaluate fuel consumption rate and remaining runtime of running engine.
+// Rate is estimated from fuel level history of current run within fuel window.
+// History is cleared when engine stops and restarted when fuel level rises.
+func (c *checkPowerGen) autonomy(check *checkResult, i godevman.GenInfo) {
+	if !c.engineRunning(i) || !i.FuelLevel.IsSet {
+		c.state.Fuel = nil
+		return
+	}
+
+	t := now()
+	level := sensorValue(i.FuelLevel)
+	hist := c.state.Fuel
+	if n := len(hist); n > 0 && level > hist[n-1].Level {
+		hist = nil
+	}
+	hist = append(hist, fuelSample{Time: t, Level: level})
+
+	hist = trimHistory(hist, t.Add(-c.subParams.fuelWindow), func(s fuelSample) time.Time { return s.Time })
+	c.state.Fuel = hist
+
+	span := t.Sub(hist[0].Time)
+	if span < minFuelSpan {
+		check.AddMsg(0, "Fuel Autonomy: estimating", "")
+		return
+	}
+
+	tank := c.subParams.tank
+	rate := (hist[0].Level - level) / 100 * tank / span.Hours()
+	check.AddMsg(0, fmt.Sprintf("Fuel Rate: %.1fl/h", rate), "")
+	check.AddPerf(newPerf("Fuel Rate", round(rate, 2)).Min(0))
+
+	// No measurable consumption
+	if rate <= 0 {
+		return
+	}
+
+	hours := round(level/100*tank/rate, 2)
+	w, cr := c.subParams.wAut, c.subParams.cAut
+	l := check.AlarmLevel(hours, w, cr)
+	check.AddMsg(l, fmt.Sprintf("Fuel Autonomy: %.1fh", hours), "")
+	check.AddPerf(newPerf("Fuel Autonomy", hours).Thresholds(w, cr).Min(0))
+}
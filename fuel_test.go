@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestPowerGenAutonomy(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	runs := []struct {
+		min     int
+		engine  string
+		fuel    uint64
+		state   int
+		summary string
+		perf    string
+	}{
+		{0, "Ready", 80, 0, "", ""},
+		{5, "Loaded", 80, 0, "Fuel Autonomy: estimating", ""},
+		{20, "Loaded", 79, 0, "Fuel Rate: 40.0l/h; Fuel Autonomy: 19.8h", "'Fuel Rate'=40;;;0; 'Fuel Autonomy'=19.75;7:;2:;0;"},
+		{65, "Loaded", 70, 0, "Fuel Rate: 100.0l/h; Fuel Autonomy: 7.0h", "'Fuel Rate'=100;;;0; 'Fuel Autonomy'=7;7:;2:;0;"},
+		{125, "Loaded", 64, 0, "Fuel Rate: 60.0l/h; Fuel Autonomy: 10.7h", ""},
+		{185, "Loaded", 50, 1, "Fuel Autonomy: 3.6h(w)", ""},
+		{190, "Loaded", 90, 0, "Fuel Autonomy: estimating", ""},
+		{195, "Ready", 90, 0, "", ""},
+	}
+
+	for _, r := range runs {
+		i := testGenInfo()
+		i.EngineState = valStr(r.engine)
+		i.FuelLevel = sensor("%", r.fuel, 0)
+		res := runStateful(t, dir, start.Add(time.Duration(r.min)*time.Minute), i, "-t", "all", "-tank", "1000", "-wa", "7:", "-engine", "Loaded=ok", "-power", "transfer=ok")
+
+		var engine string
+		for _, s := range res.sections {
+			if strings.HasPrefix(s, "Engine") {
+				engine = s
+			}
+		}
+		if !strings.Contains(engine, r.summary) {
+			t.Errorf("%d min - expected %q in %q", r.min, r.summary, engine)
+		}
+		if r.summary == "" && strings.Contains(engine, "Fuel Autonomy") {
+			t.Errorf("%d min - unexpected autonomy in %q", r.min, engine)
+		}
+		if r.perf != "" && !strings.Contains(res.PerfData(), r.perf) {
+			t.Errorf("%d min - expected %q in %q", r.min, r.perf, res.PerfData())
+		}
+		if res.RetVal() != r.state {
+			t.Errorf("%d min - expected state %d, got %d (%s)", r.min, r.state, res.RetVal(), res.Summary())
+		}
+	}
+}
+
+// Engine state is not available in engine section. Running hours are used instead
+func TestPowerGenEngineRunningByHours(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	runs := []struct {
+		min     int
+		hours   uint64
+		running bool
+	}{
+		{0, 617, false},
+		{5, 617, false},
+		{10, 618, true},
+		{20, 618, true},
+		{30, 618, false},
+		{35, 619, true},
+	}
+
+	for _, r := range runs {
+		setNow(t, start.Add(time.Duration(r.min)*time.Minute))
+		i := testGenInfo()
+		i.EngineState = godevman.ValString{}
+		i.RunHours = sensor("h", r.hours, 10)
+
+		c := newTestPowerGen(t, "-t", "engine", "-tank", "1000")
+		c.stateDir = dir
+		c.loadState()
+		if running := c.engineRunning(i); running != r.running {
+			t.Errorf("%d min - expected running %v, got %v", r.min, r.running, running)
+		}
+		if err := c.saveState(i); err != nil {
+			t.Fatalf("save state: %v", err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Fuel theft and refuel event detection
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aretaja/godevman"
+)
+
+// Detect fuel level changes. Fuel level drop from baseline while engine is stopped and
+// running hours are unchanged is alarmed as possible theft or leak, so slow leak is alarmed
+// when total drop exceeds thresholds. Baseline is reset when engine runs and on refuel.
+// Fuel level rise of at least minimal refuel level since previous check is reported as refuel.
+// Fuel drop alarm is held for event hold period.
+func (c *checkPowerGen) fuelEvents(check *checkResult, i godevman.GenInfo) {
+	if !i.FuelLevel.IsSet {
+		return
+	}
+
+	t := now()
+	st := &c.state
+	level, rh := sensorValue(i.FuelLevel), sensorValue(i.RunHours)
+	prev, prevRh, since := st.FuelLevel, st.FuelRunHours, st.FuelTime
+	st.FuelLevel, st.FuelRunHours, st.FuelTime = level, rh, t
+
+	if since.IsZero() {
+		st.FuelBase, st.FuelBaseTime = level, t
+		return
+	}
+
+	p := c.subParams
+	e, held := c.activeEvent("fuel_drop")
+	if rise := round(level-prev, 1); rise >= p.refuelMin {
+		check.AddMsg(0, "Refuel: "+c.fuelAmount(rise), fmt.Sprintf("Refuel of %s since %s", c.fuelAmount(rise), since.Format("2006-01-02 15:04")))
+		st.FuelBase, st.FuelBaseTime = level, t
+	}
+
+	if c.engineRunning(i) || i.RunHours.IsSet && rh != prevRh {
+		st.FuelBase, st.FuelBaseTime = level, t
+	} else {
+		drop := math.Max(round(st.FuelBase-level, 1), 0)
+		l := thresholdLevel(drop, p.wFuelDrop, p.cFuelDrop)
+		if l > 0 {
+			e, held = c.holdEvent("fuel_drop", heldEvent{Level: l, Value: drop, Since: st.FuelBaseTime}), true
+		}
+		check.AddPerf(newPerf("Fuel Drop", drop).Unit("%").Thresholds(p.wFuelDrop, p.cFuelDrop).Range(0, 100))
+	}
+
+	if held {
+		check.Alarm(e.Level)
+		check.AddMsg(e.Level, "Fuel Drop: "+c.fuelAmount(e.Value),
+			fmt.Sprintf("Fuel level dropped %s while engine stopped since %s", c.fuelAmount(e.Value), e.Since.Format("2006-01-02 15:04")))
+	}
+}
+
+// Returns fuel amount of level change in percents. Volume is added if tank capacity is known
+func (c *checkPowerGen) fuelAmount(pct float64) string {
+	if c.subParams.tank > 0 {
+		return fmt.Sprintf("%.1f%% (%.0fl)", pct, pct/100*c.subParams.tank)
+	}
+
+	return fmt.Sprintf("%.1f%%", pct)
+}
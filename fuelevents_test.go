@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestPowerGenFuelEvents(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2023, 5, 1, 9, 0, 0, 0, time.Local)
+	runs := []struct {
+		min    int
+		level  uint64
+		hours  uint64
+		engine string
+		state  int
+		msgs   []string
+		perf   string
+	}{
+		{0, 73, 617, "Ready", 0, nil, ""},
+		{60, 72, 617, "Ready", 0, nil, "'Fuel Drop'=1%;3;10;0;100"},
+		{120, 70, 617, "Ready", 0, nil, "'Fuel Drop'=3%;3;10;0;100"},
+		{180, 69, 617, "Ready", 1, []string{"Fuel Drop: 4.0% (80l)(w)"}, "'Fuel Drop'=4%;3;10;0;100"},
+		{240, 60, 617, "Ready", 2, []string{"Fuel Drop: 13.0% (260l)(c)"}, "'Fuel Drop'=13%;3;10;0;100"},
+		{270, 90, 617, "Ready", 2, []string{"Fuel Drop: 13.0% (260l)(c)", "Refuel: 30.0% (600l)"}, "'Fuel Drop'=0%;3;10;0;100"},
+		{310, 90, 617, "Ready", 0, nil, "'Fuel Drop'=0%;3;10;0;100"},
+		{370, 80, 627, "Running", 0, nil, ""},
+		{430, 75, 628, "Ready", 0, nil, ""},
+		{490, 70, 628, "Ready", 1, []string{"Fuel Drop: 5.0% (100l)(w)"}, "'Fuel Drop'=5%;3;10;0;100"},
+	}
+
+	for _, r := range runs {
+		i := testGenInfo()
+		i.FuelLevel = sensor("%", r.level, 0)
+		i.RunHours = sensor("h", r.hours, 10)
+		i.EngineState = valStr(r.engine)
+		res := runStateful(t, dir, start.Add(time.Duration(r.min)*time.Minute), i, "-t", "engine", "-detect-fuel", "-tank", "2000")
+
+		if res.RetVal() != r.state {
+			t.Errorf("%d min - expected state %d, got %d (%s)", r.min, r.state, res.RetVal(), res.Summary())
+		}
+		for _, m := range r.msgs {
+			if !strings.Contains(res.Summary(), m) {
+				t.Errorf("%d min - expected %q in %q", r.min, m, res.Summary())
+			}
+		}
+		if r.msgs == nil && (strings.Contains(res.Summary(), "Fuel Drop") || strings.Contains(res.Summary(), "Refuel")) {
+			t.Errorf("%d min - unexpected fuel event in %q", r.min, res.Summary())
+		}
+		if r.perf == "" && strings.Contains(res.PerfData(), "Fuel Drop") {
+			t.Errorf("%d min - unexpected perfdata in %q", r.min, res.PerfData())
+		}
+		if !strings.Contains(res.PerfData(), r.perf) {
+			t.Errorf("%d min - expected %q in %q", r.min, r.perf, res.PerfData())
+		}
+	}
+}
+
+func TestFuelAmount(t *testing.T) {
+	c := newTestPowerGen(t, "-t", "engine")
+	if s := c.fuelAmount(12.5); s != "12.5%" {
+		t.Errorf("expected 12.5%%, got %s", s)
+	}
+
+	c = newTestPowerGen(t, "-t", "engine", "-tank", "800")
+	if s := c.fuelAmount(12.5); s != "12.5% (100l)" {
+		t.Errorf("expected 12.5%% (100l), got %s", s)
+	}
+}
+
+func TestPowerGenFuelEventsNoHold(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	runs := []struct {
+		min   int
+		level uint64
+		state int
+		msg   string
+	}{
+		{0, 73, 0, ""},
+		{60, 50, 2, "Fuel Drop: 23.0%(c)"},
+		{120, 50, 2, "Fuel Drop: 23.0%(c)"},
+		{180, 90, 0, "Refuel: 40.0%"},
+	}
+
+	for _, r := range runs {
+		i := testGenInfo()
+		i.FuelLevel = sensor("%", r.level, 0)
+		setMovingNow(t, start.Add(time.Duration(r.min)*time.Minute))
+		p := checkParams{devices: &fakeDevice{Gen: &i}, stateDir: dir, devParams: godevman.Dparams{Ip: "192.0.2.1"}}
+		res := newTestPowerGen(t, "-t", "engine", "-detect-fuel", "-event-hold", "0").Run(context.Background(), p)
+
+		if res.RetVal() != r.state {
+			t.Errorf("%d min - expected state %d, got %d (%s)", r.min, r.state, res.RetVal(), res.Summary())
+		}
+		if r.msg != "" && !strings.Contains(res.Summary(), r.msg) {
+			t.Errorf("%d min - expected %q in %q", r.min, r.msg, res.Summary())
+		}
+		if r.msg == "" && strings.Contains(res.Summary(), "Fuel Drop:") {
+			t.Errorf("%d min - unexpected fuel drop message in %q", r.min, res.Summary())
+		}
+	}
+}
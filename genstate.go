@@ -0,0 +1,89 @@
+// Persistent power_gen state of host
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+// Engine is considered running if running hours changed within this period
+const runHoursIdle = 15 * time.Minute
+
+// Power generator state kept between plugin runs
+type genState struct {
+	Updated      time.Time            `json:"updated"`             // time of last run
+	RunHours     float64              `json:"run_hours"`           // running hours in last run
+	RunHoursTime time.Time            `json:"run_hours_time"`      // time when running hours changed last
+	Fuel         []fuelSample         `json:"fuel,omitempty"`      // fuel level samples of current engine run
+	NumStarts    uint64               `json:"num_starts"`          // number of starts in last check
+	StartsTime   time.Time            `json:"starts_time"`         // time of last check of number of starts
+	Cycles       []cycleSample        `json:"cycles,omitempty"`    // running hours and starts history within cycling window
+	FuelLevel    float64              `json:"fuel_level"`          // fuel level in last check
+	FuelRunHours float64              `json:"fuel_run_hours"`      // running hours in last check of fuel level
+	FuelTime     time.Time            `json:"fuel_time"`           // time of last check of fuel level
+	FuelBase     float64              `json:"fuel_base"`           // fuel level since engine stop or refuel
+	FuelBaseTime time.Time            `json:"fuel_base_time"`      // time of fuel level baseline
+	Telemetry    *telemetryState      `json:"telemetry,omitempty"` // fingerprint of fetched telemetry
+	Events       map[string]heldEvent `json:"events,omitempty"`    // active alarm events by name
+}
+
+// Does check need persistent state
+func (c *checkPowerGen) stateful() bool {
+	return c.subParams.tank > 0 || c.startsSet() || c.subParams.detectCycling || c.subParams.detectFuel || c.subParams.frozen > 0
+}
+
+// State file of host and check types. Services checking different types of same host
+// have separate state, so their overlapping runs do not overwrite each other's state.
+func (c *checkPowerGen) stateFile() stateFile {
+	types, _ := genCheckTypes(c.subParams.ctype)
+	return newStateFile(c.stateDir, c.devParams.Ip, c.Name()+"_"+strings.Join(types, "-"))
+}
+
+// Load state of host. Unreadable state is logged and replaced by empty state
+func (c *checkPowerGen) loadState() {
+	c.state = genState{}
+	if err := c.stateFile().Load(&c.state); err != nil {
+		logs.Warnf("%v. Starting with empty state", err)
+		c.state = genState{}
+	}
+}
+
+// Update running hours in state and save state of host
+func (c *checkPowerGen) saveState(i godevman.GenInfo) error {
+	t := now()
+	if i.RunHours.IsSet {
+		rh := sensorValue(i.RunHours)
+		if rh != c.state.RunHours && !c.state.Updated.IsZero() {
+			c.state.RunHoursTime = t
+		}
+		c.state.RunHours = rh
+	}
+	c.state.Updated = t
+
+	return c.stateFile().Save(c.state)
+}
+
+// Is engine running. Engine state is used if available,
+// otherwise running hours changes between runs are used.
+func (c *checkPowerGen) engineRunning(i godevman.GenInfo) bool {
+	if i.EngineState.IsSet {
+		return inList(runningEngineStates, i.EngineState.Value)
+	}
+	if !i.RunHours.IsSet || c.state.Updated.IsZero() {
+		return false
+	}
+
+	return sensorValue(i.RunHours) != c.state.RunHours || now().Sub(c.state.RunHoursTime) <= runHoursIdle
+}
+
+// Returns history without samples older than cut. One sample older than cut is kept,
+// so history covers whole window. Samples are in time order.
+func trimHistory[S any](hist []S, cut time.Time, ts func(S) time.Time) []S {
+	for len(hist) > 2 && !ts(hist[1]).After(cut) {
+		hist = hist[1:]
+	}
+
+	return hist
+}
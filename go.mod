@@ -5,12 +5,14 @@ go 1.19
 // For local development
 // replace github.com/aretaja/godevman => ../godevman
 
-require github.com/aretaja/godevman v0.0.1-devel.3
+require (
+	github.com/aretaja/godevman v0.0.1-devel.3
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require google.golang.org/genproto/googleapis/rpc v0.0.0-20231016165738-49dd2c1f3d0b // indirect
 
 require (
-	github.com/aretaja/icingahelper v1.1.1
 	github.com/aretaja/snmphelper v1.1.3 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
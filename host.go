@@ -0,0 +1,108 @@
+// Host address parsing and name resolution
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SNMP port. godevman doesn't support other ports
+const snmpPort = 161
+
+// Name resolver. Replaceable in tests
+var lookupIP = net.DefaultResolver.LookupIP
+
+// Resolve host to ip address.
+// Accepted forms: ip, hostname, host:port, bracketed IPv6 with optional port ([2001:db8::1]:161).
+// network is "ip4", "ip6" or "ip" for any address family.
+func resolveHost(ctx context.Context, host, network string) (string, error) {
+	if host == "" {
+		return "", fmt.Errorf("host is required")
+	}
+
+	name, err := splitHostPort(host)
+	if err != nil {
+		return "", err
+	}
+
+	// Literal ip
+	if ip := net.ParseIP(name); ip != nil {
+		if !ipMatches(ip, network) {
+			return "", fmt.Errorf("host %s is not %s address", name, familyName(network))
+		}
+		return ip.String(), nil
+	}
+
+	ips, err := lookupIP(ctx, network, name)
+	if err != nil {
+		return "", fmt.Errorf("can't resolve host %s: %v", name, err)
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("can't resolve host %s: no %s addresses", name, familyName(network))
+	}
+
+	return ips[0].String(), nil
+}
+
+// Remove optional port from host. Only SNMP port is accepted
+func splitHostPort(host string) (string, error) {
+	var name, port string
+	switch {
+	case strings.HasPrefix(host, "["):
+		if strings.HasSuffix(host, "]") {
+			name = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+			break
+		}
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			return "", fmt.Errorf("not valid host %s: %v", host, err)
+		}
+		name, port = h, p
+	case strings.Count(host, ":") == 1:
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			return "", fmt.Errorf("not valid host %s: %v", host, err)
+		}
+		name, port = h, p
+	default:
+		// Hostname, IPv4 or not bracketed IPv6 without port
+		name = host
+	}
+
+	if port != "" {
+		if p, err := strconv.Atoi(port); err != nil || p != snmpPort {
+			return "", fmt.Errorf("not supported port %s, only %d can be used", port, snmpPort)
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("not valid host %s", host)
+	}
+
+	return name, nil
+}
+
+// Check ip address family
+func ipMatches(ip net.IP, network string) bool {
+	switch network {
+	case "ip4":
+		return ip.To4() != nil
+	case "ip6":
+		return ip.To4() == nil
+	}
+
+	return true
+}
+
+func familyName(network string) string {
+	switch network {
+	case "ip4":
+		return "IPv4"
+	case "ip6":
+		return "IPv6"
+	}
+
+	return "IP"
+}
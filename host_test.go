@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestResolveHost(t *testing.T) {
+	orig := lookupIP
+	defer func() { lookupIP = orig }()
+	lookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+		if host != "gen1.example.net" {
+			return nil, fmt.Errorf("no such host")
+		}
+		if network == "ip6" {
+			return []net.IP{net.ParseIP("2001:db8::10")}, nil
+		}
+		return []net.IP{net.ParseIP("192.0.2.10")}, nil
+	}
+
+	tests := []struct {
+		host, network, exp string
+		err                bool
+	}{
+		{"192.0.2.1", "ip", "192.0.2.1", false},
+		{"192.0.2.1:161", "ip", "192.0.2.1", false},
+		{"2001:db8::1", "ip", "2001:db8::1", false},
+		{"[2001:db8::1]", "ip", "2001:db8::1", false},
+		{"[2001:db8::1]:161", "ip6", "2001:db8::1", false},
+		{"gen1.example.net", "ip", "192.0.2.10", false},
+		{"gen1.example.net:161", "ip6", "2001:db8::10", false},
+		{"192.0.2.1", "ip6", "", true},
+		{"[2001:db8::1]:1161", "ip", "", true},
+		{"[2001:db8::1", "ip", "", true},
+		{"gen2.example.net", "ip", "", true},
+		{"", "ip", "", true},
+	}
+
+	for _, tt := range tests {
+		ip, err := resolveHost(context.Background(), tt.host, tt.network)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s %s - expected error, got %s", tt.host, tt.network, ip)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s - unexpected error: %v", tt.host, tt.network, err)
+			continue
+		}
+		if ip != tt.exp {
+			t.Errorf("%s %s - expected %s, got %s", tt.host, tt.network, tt.exp, ip)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Levelled logging to stderr or file. Never mixed with plugin output
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// Log levels
+const (
+	levelError = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+var levelNames = []string{"error", "warn", "info", "debug"}
+
+// Levelled logger
+type logger struct {
+	l     *log.Logger
+	level int
+}
+
+// Plugin logger. Logs warnings and errors to stderr by default
+var logs = newLogger(os.Stderr, levelWarn)
+
+// Initialize new logger with timestamps
+func newLogger(w io.Writer, level int) *logger {
+	return &logger{
+		l:     log.New(w, "", log.Ldate|log.Ltime|log.Lmicroseconds|log.Lshortfile),
+		level: level,
+	}
+}
+
+// Returns log level by name
+func parseLevel(name string) (int, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return i, nil
+		}
+	}
+
+	return levelWarn, fmt.Errorf("unknown log level %s, valid levels: %s", name, strings.Join(levelNames, "|"))
+}
+
+// Setup plugin logger. Empty path keeps logging to stderr
+func setupLogger(path string, level int) error {
+	var w io.Writer = os.Stderr
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("open debug file: %v", err)
+		}
+		w = f
+	}
+
+	logs = newLogger(w, level)
+
+	return nil
+}
+
+func (lg *logger) logf(level int, format string, v ...any) {
+	if level > lg.level {
+		return
+	}
+	lg.l.Output(3, strings.ToUpper(levelNames[level])+": "+fmt.Sprintf(format, v...))
+}
+
+func (lg *logger) Errorf(format string, v ...any) {
+	lg.logf(levelError, format, v...)
+}
+
+func (lg *logger) Warnf(format string, v ...any) {
+	lg.logf(levelWarn, format, v...)
+}
+
+func (lg *logger) Infof(format string, v ...any) {
+	lg.logf(levelInfo, format, v...)
+}
+
+func (lg *logger) Debugf(format string, v ...any) {
+	lg.logf(levelDebug, format, v...)
+}
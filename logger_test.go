@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	var buf bytes.Buffer
+	lg := newLogger(&buf, levelInfo)
+
+	lg.Errorf("error %d", 1)
+	lg.Warnf("warn %d", 2)
+	lg.Infof("info %d", 3)
+	lg.Debugf("debug %d", 4)
+
+	out := buf.String()
+	for _, exp := range []string{"ERROR: error 1", "WARN: warn 2", "INFO: info 3"} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("expected %q in log, got %q", exp, out)
+		}
+	}
+	if strings.Contains(out, "debug 4") {
+		t.Errorf("unexpected debug message in log: %q", out)
+	}
+	if !strings.Contains(out, "logger_test.go") {
+		t.Errorf("expected caller file in log, got %q", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := parseLevel("DEBUG"); err != nil || l != levelDebug {
+		t.Errorf("expected debug level, got %d (%v)", l, err)
+	}
+	if _, err := parseLevel("trace"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -6,12 +6,14 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"sort"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aretaja/godevman"
 	"github.com/kr/pretty"
@@ -20,29 +22,25 @@ import (
 // Version of release
 const Version = "0.0.2"
 
-// available checks
-var checksInfo = map[string][]string{
-	"power_gen": {"Power generator state checks.",
-		"\tAlarms are based on provided or default arguments."},
-	"sync_state": {"Syncronisation state check (Freq and Phase sync).",
-		"\tCRITICAL - fsync signal not locked or psync not phase aligned.",
-		"\tWARNING - sync source quality is bad.",
-		"\tProvides long output and no performance data."},
-}
-
 // check options
 type checkParams struct {
 	execName  string
 	checkName string
 	subCheck  string
 	subArgs   []string
+	devices   deviceProvider
 	devParams godevman.Dparams
-	dbg       bool
+	stateDir  string
+	timeout   time.Duration
+	backoff   time.Duration
+	retries   int
 }
 
 // Initialize CheckArgs using submitted command line options
 func initParams() (checkParams, error) {
-	H := flag.String("H", "", "<host ip>")
+	H := flag.String("H", "", "<host> ip, hostname or bracketed IPv6 with optional port ([2001:db8::1]:161)")
+	ip4 := flag.Bool("4", false, "Use IPv4 address when resolving host name")
+	ip6 := flag.Bool("6", false, "Use IPv6 address when resolving host name")
 	V := flag.Int("V", 2, "[snmp version] (1|2|3)")
 	u := flag.String("u", "public", "[username|community]")
 	a := flag.String("a", "MD5", "[authentication protocol] (NoAuth|MD5|SHA)")
@@ -50,11 +48,37 @@ func initParams() (checkParams, error) {
 	l := flag.String("l", "authPriv", "[security level] (noAuthNoPriv|authNoPriv|authPriv)")
 	x := flag.String("x", "DES", "[privacy protocol] (NoPriv|DES|AES|AES192|AES256|AES192C|AES256C)")
 	X := flag.String("X", "", "[privacy protocol pass phrase]")
-	d := flag.Bool("d", false, "Using this parameter will print out debug info")
+	conf := flag.String("config", "", "[config file] (default \""+defaultConfigPath+"\" or CHECK_GODEVMAN_CONFIG env)")
+	prof := flag.String("profile", "", "[credential profile name from config file]")
+	sec := flag.String("secrets", "", "[secrets file] with -u, -A, -X values as <flag name>=<value> lines. \"-\" reads from stdin")
+	rec := flag.String("record", "", "[file] Save device responses to JSON snapshot file")
+	rep := flag.String("replay", "", "[file] Run check against JSON snapshot file instead of device")
+	sd := flag.String("state-dir", defaultStateDir, "[state directory] where checks keep per host state between runs")
+	t := flag.Duration("timeout", 50*time.Second, "[timeout] for whole device polling. 0 disables it")
+	r := flag.Int("retries", 0, "[retries] of failed device requests")
+	b := flag.Duration("backoff", time.Second, "[initial retry delay]. Doubles after every retry")
+	d := flag.Bool("d", false, "Using this parameter will print out debug info. Same as -log-level debug")
+	ll := flag.String("log-level", "warn", "[log level] (error|warn|info|debug)")
+	df := flag.String("debug-file", "", "[log file]. Logs go to stderr if not set")
 	v := flag.Bool("v", false, "Using this parameter will display the version number and exit")
 	usage := flag.Bool("usage", false, "Using this parameter will display general usage info and exit")
 
-	flag.Parse()
+	flag.CommandLine.Init(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	if err := parseFlags(flag.CommandLine, os.Args[1:]); err != nil {
+		return checkParams{}, err
+	}
+
+	// Setup logging
+	level, err := parseLevel(*ll)
+	if err != nil {
+		return checkParams{}, err
+	}
+	if *d {
+		level = levelDebug
+	}
+	if err := setupLogger(*df, level); err != nil {
+		return checkParams{}, err
+	}
 
 	params := checkParams{
 		devParams: godevman.Dparams{
@@ -69,8 +93,23 @@ func initParams() (checkParams, error) {
 				PrivPass: *X,
 			},
 		},
-		dbg: *d,
+		stateDir: *sd,
+		timeout:  *t,
+		backoff:  *b,
+		retries:  *r,
+	}
+
+	// Address family for host name resolution
+	network := "ip"
+	switch {
+	case *ip4 && *ip6:
+		return params, fmt.Errorf("-4 and -6 can't be used together")
+	case *ip4:
+		network = "ip4"
+	case *ip6:
+		network = "ip6"
 	}
+	params.devices = snmpProvider{network: network}
 
 	// Get executable name
 	n, err := os.Executable()
@@ -88,14 +127,8 @@ func initParams() (checkParams, error) {
 	// Show usage info
 	if *usage {
 		fmt.Printf("Usage:\n\t%s <common args> <check_name> [check args]\n\n\tAvailable checks:\n", params.execName)
-		keys := make([]string, 0, len(checksInfo))
-		for k := range checksInfo {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-
-		for _, k := range keys {
-			fmt.Printf("\t\t%s - %s\n", k, strings.Join(checksInfo[k], "\n\t\t"))
+		for _, k := range checkNames() {
+			fmt.Printf("\t\t%s - %s\n", k, strings.Join(checks[k].Info(), "\n\t\t"))
 		}
 		fmt.Print("\n\tTo get info of available common arguments:\n\t\tcheck-godevman-multi --help\n" +
 			"\tTo get info of available check arguments:\n\t\tcheck-godevman-multi <common args> <check_name> --help\n")
@@ -109,54 +142,145 @@ func initParams() (checkParams, error) {
 	}
 
 	params.subCheck, params.subArgs = rargs[0], rargs[1:]
-	// DEBUG
-	if params.dbg {
-		fmt.Printf("params: %# v\n", pretty.Formatter(params))
+
+	// Explicitly set flags take precedence over profile and secrets
+	setFlags := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	// Apply credential profile
+	if *prof != "" {
+		cfg, err := loadConfig(configPath(*conf))
+		if err != nil {
+			return params, err
+		}
+		if err := cfg.applyProfile(&params, *prof, setFlags); err != nil {
+			return params, err
+		}
+	}
+
+	// Apply secrets from file and env. Env takes precedence
+	if *sec != "" {
+		s, err := readSecrets(*sec, os.Stdin)
+		if err != nil {
+			return params, err
+		}
+		applySecrets(&params, s, setFlags)
+	}
+	applySecrets(&params, envSecrets(), setFlags)
+
+	// Record and replay mode
+	if *rec != "" && *rep != "" {
+		return params, fmt.Errorf("-record and -replay can't be used together")
+	}
+
+	if *rep != "" {
+		fd, err := loadSnapshot(*rep)
+		if err != nil {
+			return params, err
+		}
+		params.devices = fd
+	}
+
+	if *rec != "" {
+		params.devices = recordProvider{base: params.devices, path: *rec}
 	}
 
+	// DEBUG
+	logs.Debugf("params: %# v", pretty.Formatter(params.redacted()))
+
 	return params, nil
 }
 
+// Exit codes:
+//
+//	0-3 - check result state (OK, WARNING, CRITICAL, UNKNOWN)
+//	3   - configuration or argument parse error. One line plugin output is printed
+//	3   - help (-h, --help, -usage, -info) or version (-v) output
+//
+// All output goes to stdout.
 func main() {
-	log.SetFlags(log.Ldate | log.Lmicroseconds | log.Lshortfile)
-
 	p, err := initParams()
 	if err != nil {
-		log.Printf("error: initParams - %v\n", err)
-		os.Exit(3)
+		exitUnknown(err)
+	}
+
+	c, ok := checks[p.subCheck]
+	if !ok {
+		exitUnknown(fmt.Errorf("unrecognized check name - %s", p.subCheck))
 	}
+	p.checkName = c.Name()
+
+	if err := initSubParams(c, &p); err != nil {
+		exitUnknown(fmt.Errorf("%s: %v", p.checkName, err))
+	}
+
+	res := runCheck(c, p)
+	fmt.Print(res.Output())
+	os.Exit(res.RetVal())
+}
+
+// Print one line plugin output about configuration error and exit with UNKNOWN
+func exitUnknown(err error) {
+	fmt.Printf("UNKNOWN - %v\n", err)
+	os.Exit(3)
+}
 
-	switch p.subCheck {
-	case "sync_state":
-		p.checkName = "sync_state"
-		c := checkSyncro{}
-		c.checkParams = p
-		c.state()
-	case "power_gen":
-		p.checkName = "power_gen"
-		c := checkPowerGen{}
-		c.checkParams = p
-		c.state()
-	default:
-		log.Printf("error: unrecognized check name - %s\n", p.subCheck)
+// Parse flags. Usage is printed to stdout on help request. Parse errors are returned
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	err := fs.Parse(args)
+	if err == flag.ErrHelp {
+		fs.SetOutput(os.Stdout)
+		fmt.Printf("Usage of %s:\n", fs.Name())
+		fs.PrintDefaults()
 		os.Exit(3)
 	}
+
+	return err
 }
 
-// Initialize device
-func (sd *checkParams) initDevice() any {
-	p := sd.devParams
-	device, err := godevman.NewDevice(&p)
-	if err != nil {
-		log.Printf("error: godevman.NewDevice: %v", err)
+// Run check within global timeout
+func runCheck(c Check, p checkParams) *checkResult {
+	ctx := context.Background()
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	return c.Run(ctx, p)
+}
+
+// Parse check specific arguments
+func initSubParams(c Check, p *checkParams) error {
+	flag := flag.NewFlagSet(p.checkName, flag.ContinueOnError)
+	c.SetFlags(flag)
+	var info = flag.Bool("info", false, "About check")
+
+	if err := parseFlags(flag, p.subArgs); err != nil {
+		return err
+	}
+	if flag.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments - %s", strings.Join(flag.Args(), " "))
+	}
+
+	// Show info about check
+	if *info {
+		fmt.Printf("%s: %s\n", p.checkName, strings.Join(c.Info(), "\n"))
 		os.Exit(3)
 	}
 
-	md := device.Morph()
-	// DEBUG
-	if sd.dbg {
-		fmt.Printf("godevman morphed device: %# v\n", pretty.Formatter(md))
+	return nil
+}
+
+// Initialize device using device provider
+func (sd *checkParams) initDevice(ctx context.Context) (any, error) {
+	if sd.devices == nil {
+		return nil, fmt.Errorf("device provider is not defined")
 	}
 
-	return md
+	logs.Infof("initialize device %s", sd.devParams.Ip)
+	return fetch(ctx, sd, "device info", func() (any, error) {
+		return sd.devices.Device(ctx, sd.devParams)
+	})
 }
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestInitSubParams(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{"valid", []string{"-t", "engine", "-wt", "95"}, false},
+		{"unknown flag", []string{"-t", "engine", "-wq", "95"}, true},
+		{"missing value", []string{"-t"}, true},
+		{"extra args", []string{"-t", "engine", "95"}, true},
+		{"malformed threshold", []string{"-t", "electrical", "-cv", "250:210"}, true},
+		{"not a number", []string{"-t", "engine", "-ct", "x:y"}, true},
+		{"per phase threshold", []string{"-t", "electrical", "-wmv", "230:240,,235:245"}, false},
+		{"two phase thresholds", []string{"-t", "electrical", "-wgv", "230:240,235:245"}, true},
+		{"single phase", []string{"-t", "electrical", "-phases", "1"}, false},
+		{"two phases", []string{"-t", "electrical", "-phases", "2"}, true},
+		{"state policy", []string{"-t", "common", "-mode", "Man=warning", "-breaker", "IslOper=ok"}, false},
+		{"bad state policy", []string{"-t", "common", "-mode", "Man=maybe"}, true},
+		{"service date", []string{"-t", "engine", "-service-date", "2023-04-01", "-service-days", "365"}, false},
+		{"bad service date", []string{"-t", "engine", "-service-date", "01.04.2023"}, true},
+		{"missing service file", []string{"-t", "engine", "-service-file", "/nonexistent/service.yaml"}, true},
+		{"exercise window", []string{"-t", "engine", "-exercise", "Mon,Thu 10:00-10:30"}, false},
+		{"bad exercise window", []string{"-t", "engine", "-exercise", "Mon 10:00"}, true},
+		{"missing policy file", []string{"-t", "common", "-policy", "/nonexistent/policy.yaml"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := checkParams{checkName: "power_gen", subArgs: tt.args}
+			err := initSubParams(&checkPowerGen{}, &p)
+			if (err != nil) != tt.err {
+				t.Errorf("error - expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
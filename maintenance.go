@@ -0,0 +1,134 @@
+// Maintenance interval of generator based on running hours and date
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aretaja/godevman"
+	"gopkg.in/yaml.v3"
+)
+
+// Date format of service dates
+const dateFormat = "2006-01-02"
+
+// Last service and service interval of generator. Zero values are not set
+type serviceInfo struct {
+	Hours    float64   `yaml:"hours"`         // running hours at last service
+	Date     dateValue `yaml:"date"`          // date of last service
+	Interval float64   `yaml:"interval"`      // service interval in running hours
+	Days     int       `yaml:"interval_days"` // service interval in days
+}
+
+// Date. Implements flag.Value. Format is YYYY-MM-DD
+type dateValue struct {
+	time.Time
+}
+
+func (d *dateValue) Set(s string) error {
+	t, err := time.ParseInLocation(dateFormat, s, time.Local)
+	if err != nil {
+		return fmt.Errorf("not valid date - %s: expected YYYY-MM-DD", s)
+	}
+	d.Time = t
+
+	return nil
+}
+
+func (d *dateValue) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format(dateFormat)
+}
+
+func (d *dateValue) UnmarshalYAML(n *yaml.Node) error {
+	return d.Set(n.Value)
+}
+
+// Service file with maintenance data of generators by host. Implements flag.Value, so file is loaded on argument parsing.
+//
+//	gen1.example.com:
+//	  hours: 1200
+//	  date: 2023-04-01
+//	  interval: 250
+//	  interval_days: 365
+type serviceFile struct {
+	path  string
+	hosts map[string]serviceInfo
+}
+
+// Load service file
+func (f *serviceFile) Set(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read service file: %v", err)
+	}
+
+	var hosts map[string]serviceInfo
+	if err := yaml.Unmarshal(b, &hosts); err != nil {
+		return fmt.Errorf("parse service file %s: %v", path, err)
+	}
+	f.path, f.hosts = path, hosts
+
+	return nil
+}
+
+func (f *serviceFile) String() string {
+	return f.path
+}
+
+// Returns service info of generator. Values set by flags override service file values of host
+func (c *checkPowerGen) serviceInfo() serviceInfo {
+	s := c.subParams.service
+	fs := c.subParams.serviceFile.hosts[c.devParams.Ip]
+	if s.Hours == 0 {
+		s.Hours = fs.Hours
+	}
+	if s.Date.IsZero() {
+		s.Date = fs.Date
+	}
+	if s.Interval == 0 {
+		s.Interval = fs.Interval
+	}
+	if s.Days == 0 {
+		s.Days = fs.Days
+	}
+
+	return s
+}
+
+// Evaluate time until next service by running hours and, if last service date and
+// interval in days are known, by date. Overdue service gives negative values.
+func (c *checkPowerGen) maintenance(check *checkResult, i godevman.GenInfo) {
+	s := c.serviceInfo()
+	p := c.subParams
+
+	if s.Interval > 0 {
+		if i.RunHours.IsSet {
+			left := round(s.Hours+s.Interval-sensorValue(i.RunHours), 1)
+			l := check.AlarmLevel(left, p.wService, p.cService)
+			check.AddMsg(l, fmt.Sprintf("Hours Until Service: %.1fh", left), "")
+			check.AddPerf(newPerf("Hours Until Service", left).Thresholds(p.wService, p.cService).Max(s.Interval))
+		} else {
+			check.AddMsg(3, "Hours Until Service: Na", "")
+		}
+	}
+
+	if s.Days > 0 && !s.Date.IsZero() {
+		due := s.Date.AddDate(0, 0, s.Days)
+		left := round(due.Sub(now()).Hours()/24, 1)
+		l := check.AlarmLevel(left, p.wServiceDays, p.cServiceDays)
+		check.AddMsg(l, fmt.Sprintf("Days Until Service: %.1f", left), fmt.Sprintf("Last service %s, next service due %s",
+			s.Date.Format(dateFormat), due.Format(dateFormat)))
+		check.AddPerf(newPerf("Days Until Service", left).Thresholds(p.wServiceDays, p.cServiceDays).Max(float64(s.Days)))
+	}
+}
+
+// Is maintenance interval configured
+func (c *checkPowerGen) maintenanceSet() bool {
+	s := c.serviceInfo()
+	return s.Interval > 0 || s.Days > 0 && !s.Date.IsZero()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestPowerGenMaintenance(t *testing.T) {
+	setNow(t, time.Date(2023, 5, 1, 12, 0, 0, 0, time.Local))
+
+	file := filepath.Join(t.TempDir(), "service.yaml")
+	data := "192.0.2.1:\n  hours: 10\n  date: 2023-04-10\n  interval: 40\n  interval_days: 30\n" +
+		"192.0.2.2:\n  hours: 10\n  interval: 50\n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Running hours in test info are 61.7
+	tests := []struct {
+		name  string
+		host  string
+		args  []string
+		state int
+		msgs  string
+		perf  string
+	}{
+		{"not configured", "", nil, 0, "", ""},
+		{"hours ok", "", []string{"-service-hours", "0", "-service-interval", "100"}, 0,
+			"Hours Until Service: 38.3h", "'Hours Until Service'=38.3;25:;0:;;100"},
+		{"hours warning", "", []string{"-service-hours", "0", "-service-interval", "80"}, 1,
+			"Hours Until Service: 18.3h(w)", ""},
+		{"hours overdue", "", []string{"-service-hours", "0", "-service-interval", "50"}, 2,
+			"Hours Until Service: -11.7h(c)", "'Hours Until Service'=-11.7;25:;0:;;50"},
+		{"custom thresholds", "", []string{"-service-hours", "0", "-service-interval", "80", "-ws", "10:"}, 0,
+			"Hours Until Service: 18.3h", ""},
+		{"date", "", []string{"-service-date", "2023-04-01", "-service-days", "45"}, 0,
+			"Days Until Service: 14.5", "'Days Until Service'=14.5;14:;0:;;45"},
+		{"date warning", "", []string{"-service-date", "2023-04-01", "-service-days", "40"}, 1,
+			"Days Until Service: 9.5(w)", ""},
+		{"days without date", "", []string{"-service-days", "40"}, 0, "", ""},
+		{"file", "192.0.2.1", []string{"-service-file", file}, 2,
+			"Hours Until Service: -11.7h(c); Days Until Service: 8.5(w)", ""},
+		{"file other host", "192.0.2.2", []string{"-service-file", file}, 2,
+			"Hours Until Service: -1.7h(c)", ""},
+		{"flag overrides file", "192.0.2.1", []string{"-service-file", file, "-service-hours", "50", "-service-days", "60"}, 0,
+			"Hours Until Service: 28.3h; Days Until Service: 38.5", ""},
+		{"host not in file", "192.0.2.3", []string{"-service-file", file}, 0, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestPowerGen(t, tt.args...)
+			c.devParams = godevman.Dparams{Ip: tt.host}
+			check := newResult("GEN")
+			if err := c.engine(check, testGenInfo()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if check.RetVal() != tt.state {
+				t.Errorf("state - expected %d, got %d (%s)", tt.state, check.RetVal(), check.Summary())
+			}
+			if tt.msgs == "" && strings.Contains(check.Summary(), "Service") {
+				t.Errorf("unexpected service info in %q", check.Summary())
+			}
+			for _, m := range strings.Split(tt.msgs, "; ") {
+				if !strings.Contains(check.Summary(), m) {
+					t.Errorf("expected %q in %q", m, check.Summary())
+				}
+			}
+			if !strings.Contains(check.PerfData(), tt.perf) {
+				t.Errorf("expected %q in %q", tt.perf, check.PerfData())
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Performance data builder
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aretaja/godevman"
+)
+
+// Performance data item. Value, thresholds, min and max are in the same units.
+// Build with newPerf and chained setters:
+//
+//	newPerf("Fuel level", 73).Unit("%").Thresholds(w, c).Range(0, 100)
+type perfData struct {
+	label      string
+	value      float64
+	uom        string
+	warn, crit threshold
+	min, max   *float64
+}
+
+// Initialize performance data item
+func newPerf(label string, value float64) perfData {
+	return perfData{label: label, value: value}
+}
+
+// Initialize performance data item from sensor value in engineering units.
+// Sensor unit is used as UOM if it is valid performance data unit.
+func sensorPerf(label string, s godevman.SensorVal) perfData {
+	return newPerf(label, sensorValue(s)).Unit(perfUnit(s.Unit))
+}
+
+// Set unit of measurement
+func (p perfData) Unit(uom string) perfData {
+	p.uom = uom
+	return p
+}
+
+// Set warning and critical thresholds
+func (p perfData) Thresholds(warn, crit threshold) perfData {
+	p.warn, p.crit = warn, crit
+	return p
+}
+
+// Set minimum value
+func (p perfData) Min(v float64) perfData {
+	p.min = &v
+	return p
+}
+
+// Set maximum value
+func (p perfData) Max(v float64) perfData {
+	p.max = &v
+	return p
+}
+
+// Set minimum and maximum values
+func (p perfData) Range(min, max float64) perfData {
+	return p.Min(min).Max(max)
+}
+
+// Returns performance data in 'label'=value[UOM];[warn];[crit];[min];[max] format
+func (p perfData) String() string {
+	return fmt.Sprintf("'%s'=%s%s;%s;%s;%s;%s", strings.ReplaceAll(p.label, "'", "''"),
+		formatNum(p.value), p.uom, p.warn, p.crit, optNum(p.min), optNum(p.max))
+}
+
+// Returns v rounded to d decimals
+func round(v float64, d int) float64 {
+	m := math.Pow(10, float64(d))
+	return math.Round(v*m) / m
+}
+
+func optNum(v *float64) string {
+	if v == nil {
+		return ""
+	}
+
+	return formatNum(*v)
+}
+
+// Performance data units by godevman sensor units
+var perfUnits = map[string]string{
+	"V":  "V",
+	"A":  "A",
+	"kW": "kW",
+	"Hz": "Hz",
+	"%":  "%",
+	"°C": "C",
+}
+
+// Returns performance data unit for sensor unit. Unknown units are omitted
+func perfUnit(unit string) string {
+	return perfUnits[unit]
+}
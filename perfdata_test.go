@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestPerfDataString(t *testing.T) {
+	tests := []struct {
+		name string
+		p    perfData
+		exp  string
+	}{
+		{"plain", newPerf("Gen Power", 5), "'Gen Power'=5;;;;"},
+		{"thresholds", newPerf("Gen Voltage L1", 231).Unit("V").Thresholds(mustThreshold("215:245"), mustThreshold("210:250")).Min(0),
+			"'Gen Voltage L1'=231V;215:245;210:250;0;"},
+		{"range", newPerf("Fuel level", 73).Unit("%").Range(0, 100), "'Fuel level'=73%;;;0;100"},
+		{"max only", newPerf("Gen Power", 12.5).Unit("kW").Max(20), "'Gen Power'=12.5kW;;;;20"},
+		{"counter", newPerf("Number of Starts", 16).Unit("c"), "'Number of Starts'=16c;;;;"},
+		{"quote in label", newPerf("Gen's Power", 1), "'Gen''s Power'=1;;;;"},
+	}
+
+	for _, tt := range tests {
+		if s := tt.p.String(); s != tt.exp {
+			t.Errorf("%s - expected %q, got %q", tt.name, tt.exp, s)
+		}
+	}
+}
+
+func TestSensorPerf(t *testing.T) {
+	tests := []struct {
+		s   godevman.SensorVal
+		exp string
+	}{
+		{godevman.SensorVal{Unit: "V", Value: 136, Divisor: 10, IsSet: true}, "'X'=13.6V;;;;"},
+		{godevman.SensorVal{Unit: "Hz", Value: 500, Divisor: 10, IsSet: true}, "'X'=50Hz;;;;"},
+		{godevman.SensorVal{Unit: "°C", Value: 52, IsSet: true}, "'X'=52C;;;;"},
+		{godevman.SensorVal{Unit: "l", Value: 12, IsSet: true}, "'X'=12;;;;"},
+	}
+
+	for _, tt := range tests {
+		if s := sensorPerf("X", tt.s).String(); s != tt.exp {
+			t.Errorf("%s - expected %q, got %q", tt.s.Unit, tt.exp, s)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// State policy of generator status values
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// State names used in policies
+var stateNames = map[string]int{
+	"ok":       0,
+	"warning":  1,
+	"critical": 2,
+	"unknown":  3,
+}
+
+// Maps status values to result states. "*" matches all other values.
+// Implements flag.Value. Flag value is comma separated list of <value>=<state> pairs,
+// fe. "Man=warning,*=critical". Repeated flags are merged.
+type statePolicy map[string]int
+
+// Returns state of value specific rule. Value is compared case insensitively
+func (p statePolicy) rule(val string) (int, bool) {
+	for k, s := range p {
+		if k != "*" && strings.EqualFold(k, val) {
+			return s, true
+		}
+	}
+
+	return 0, false
+}
+
+// Add rule to policy
+func (p statePolicy) add(val, state string) error {
+	s, ok := stateNames[strings.ToLower(strings.TrimSpace(state))]
+	if !ok {
+		return fmt.Errorf("not valid state - %s: expected ok|warning|critical|unknown", state)
+	}
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return fmt.Errorf("missing status value for state %s", state)
+	}
+	p[val] = s
+
+	return nil
+}
+
+// Set policy rules from flag value
+func (p *statePolicy) Set(s string) error {
+	if *p == nil {
+		*p = statePolicy{}
+	}
+	for _, r := range strings.Split(s, ",") {
+		val, state, ok := strings.Cut(r, "=")
+		if !ok {
+			return fmt.Errorf("not valid state policy - %s: expected <value>=<state>", r)
+		}
+		if err := p.add(val, state); err != nil {
+			return fmt.Errorf("not valid state policy - %s: %v", r, err)
+		}
+	}
+
+	return nil
+}
+
+// Returns policy rules in flag value format
+func (p statePolicy) String() string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	names := map[int]string{}
+	for n, s := range stateNames {
+		names[s] = n
+	}
+
+	rules := make([]string, 0, len(keys))
+	for _, k := range keys {
+		rules = append(rules, k+"="+names[p[k]])
+	}
+
+	return strings.Join(rules, ",")
+}
+
+// Set policy rules from policy file value: state map
+func (p *statePolicy) UnmarshalYAML(n *yaml.Node) error {
+	var m map[string]string
+	if err := n.Decode(&m); err != nil {
+		return err
+	}
+
+	*p = statePolicy{}
+	for val, state := range m {
+		if err := p.add(val, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// State policies of generator mode, breaker and engine state and power situation
+type genPolicy struct {
+	Mode    statePolicy `yaml:"mode"`
+	Breaker statePolicy `yaml:"breaker"`
+	Engine  statePolicy `yaml:"engine"`
+	Power   statePolicy `yaml:"power"`
+}
+
+// Default generator state policy. Anything other than Auto mode,
+// MainsOper or BrksOff breaker and Ready engine is CRITICAL.
+var defaultGenPolicy = genPolicy{
+	Mode:    statePolicy{"Auto": 0, "*": 2},
+	Breaker: statePolicy{"MainsOper": 0, "BrksOff": 0, "*": 2},
+	Engine:  statePolicy{"Ready": 0, "*": 2},
+}
+
+// Generator state policy file. Implements flag.Value, so file is loaded on argument parsing.
+type policyFile struct {
+	path   string
+	policy genPolicy
+}
+
+// Load policy file
+func (f *policyFile) Set(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read policy: %v", err)
+	}
+
+	var p genPolicy
+	if err := yaml.Unmarshal(b, &p); err != nil {
+		return fmt.Errorf("parse policy %s: %v", path, err)
+	}
+	f.path, f.policy = path, p
+
+	return nil
+}
+
+func (f *policyFile) String() string {
+	return f.path
+}
+
+// Returns state of status value using policies in order of precedence.
+// Value specific rules are preferred over "*" rules. Returns CRITICAL if no policy matches.
+func policyState(val string, policies ...statePolicy) int {
+	for _, p := range policies {
+		if s, ok := p.rule(val); ok {
+			return s
+		}
+	}
+	for _, p := range policies {
+		if s, ok := p["*"]; ok {
+			return s
+		}
+	}
+
+	return 2
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatePolicySet(t *testing.T) {
+	tests := []struct {
+		val string
+		str string
+		err bool
+	}{
+		{"Man=warning", "Man=warning", false},
+		{"Man=WARNING,*=ok", "*=ok,Man=warning", false},
+		{" Auto = ok ,Test=critical", "Auto=ok,Test=critical", false},
+		{"Man", "", true},
+		{"Man=bad", "", true},
+		{"=ok", "", true},
+	}
+
+	for _, tt := range tests {
+		var p statePolicy
+		err := p.Set(tt.val)
+		if (err != nil) != tt.err {
+			t.Errorf("%q - expected error %v, got %v", tt.val, tt.err, err)
+			continue
+		}
+		if !tt.err && p.String() != tt.str {
+			t.Errorf("%q - expected %q, got %q", tt.val, tt.str, p.String())
+		}
+	}
+}
+
+func TestPolicyState(t *testing.T) {
+	flags := statePolicy{"Man": 1}
+	file := statePolicy{"Man": 2, "Test": 0, "*": 1}
+
+	tests := []struct {
+		val      string
+		policies []statePolicy
+		exp      int
+	}{
+		{"Auto", []statePolicy{nil, nil, defaultGenPolicy.Mode}, 0},
+		{"Man", []statePolicy{nil, nil, defaultGenPolicy.Mode}, 2},
+		{"auto", []statePolicy{nil, nil, defaultGenPolicy.Mode}, 0},
+		{"Man", []statePolicy{flags, file, defaultGenPolicy.Mode}, 1},
+		{"Test", []statePolicy{flags, file, defaultGenPolicy.Mode}, 0},
+		{"Off", []statePolicy{flags, file, defaultGenPolicy.Mode}, 1},
+		{"Auto", []statePolicy{flags, file, defaultGenPolicy.Mode}, 0},
+		{"Off", nil, 2},
+	}
+
+	for _, tt := range tests {
+		if s := policyState(tt.val, tt.policies...); s != tt.exp {
+			t.Errorf("%s - expected %d, got %d", tt.val, tt.exp, s)
+		}
+	}
+}
+
+func TestPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	data := `mode:
+  Man: warning
+breaker:
+  IslOper: ok
+engine:
+  Running: ok
+  "*": warning
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var f policyFile
+	if err := f.Set(path); err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	if s := f.policy.Engine.String(); s != "*=warning,Running=ok" {
+		t.Errorf("engine policy - expected %q, got %q", "*=warning,Running=ok", s)
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("mode:\n  Man: maybe\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{bad, filepath.Join(dir, "missing.yaml")} {
+		if err := f.Set(p); err == nil {
+			t.Errorf("%s - expected error, got nil", p)
+		}
+	}
+}
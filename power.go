@@ -0,0 +1,146 @@
+// Power situation of site evaluated across power_gen sections
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aretaja/godevman"
+)
+
+// Power situations. Used also as power state policy values
+const (
+	powerMains     = "mains"
+	powerGenerator = "generator"
+	powerTransfer  = "transfer"
+	powerBlackout  = "blackout"
+)
+
+// Power situation descriptions in plugin output
+var powerNames = map[string]string{
+	powerMains:     "on mains",
+	powerGenerator: "on generator",
+	powerTransfer:  "transferring",
+	powerBlackout:  "blackout",
+}
+
+// Default power state policy
+var defaultPowerPolicy = statePolicy{
+	powerMains:     0,
+	powerGenerator: 1,
+	powerTransfer:  1,
+	powerBlackout:  2,
+}
+
+// Breaker states where generator supplies load
+var genBreakerStates = []string{"IslOper", "MultIslOp"}
+
+// Breaker states during transfer between mains and generator
+var transferBreakerStates = []string{"Synchro", "RevSync", "MainsFlt", "ValidFlt", "MainsRet"}
+
+// Breaker states where mains supplies load
+var mainsBreakerStates = []string{"MainsOper", "ParalOper", "MultParOp"}
+
+// Engine states where engine is starting, running or stopping
+var runningEngineStates = []string{"Prestart", "Cranking", "Starting", "Running", "Loaded", "SoftLoad", "SoftUnld", "Cooling", "WaitStop"}
+
+func inList(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns power situation of site.
+// Breaker state is preferred. If breaker does not show generator operation or transfer,
+// mains voltage decides between mains supply, transfer (engine running) and blackout.
+// Returns empty string if there is not enough info.
+func (c *checkPowerGen) powerSituation(i godevman.GenInfo) string {
+	br := i.BreakerState.Value
+	switch {
+	case inList(genBreakerStates, br):
+		return powerGenerator
+	case inList(transferBreakerStates, br):
+		return powerTransfer
+	}
+
+	mains, known := c.mainsPresent(i)
+	switch {
+	case !known && !i.BreakerState.IsSet:
+		return ""
+	case mains:
+		return powerMains
+	case inList(runningEngineStates, i.EngineState.Value):
+		return powerTransfer
+	}
+
+	return powerBlackout
+}
+
+// Returns true if mains is present. Mains voltage is present if it is not zero and does not raise critical alarm.
+// Breaker state is used if mains voltage is not available. Second return value is false if mains state is not known.
+func (c *checkPowerGen) mainsPresent(i godevman.GenInfo) (bool, bool) {
+	volts := []godevman.SensorVal{i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3}[:c.subParams.phases]
+
+	known := false
+	for n, s := range volts {
+		if !s.IsSet || s.Unit != "V" {
+			continue
+		}
+		known = true
+
+		_, cr := c.voltThresholds(fmt.Sprintf("Mains Voltage L%d", n+1))
+		if v := sensorValue(s); v == 0 || cr.alert(v) {
+			return false, true
+		}
+	}
+
+	if known {
+		return true, true
+	}
+
+	return inList(mainsBreakerStates, i.BreakerState.Value), i.BreakerState.IsSet
+}
+
+// Returns alarm level of electrical sensor adjusted to power situation and raises result state.
+// Without known power situation alarms are not adjusted.
+// Generator alarms are suppressed if generator does not supply load.
+// Mains alarms are suppressed if mains does not supply load. Missing supply is reported by power situation.
+func (c *checkPowerGen) electricalAlarm(check *checkResult, k string, v float64, w, cr threshold) int {
+	if c.situation == "" {
+		return check.AlarmLevel(v, w, cr)
+	}
+
+	if isGenSensor(k) {
+		if c.situation != powerGenerator {
+			return check.Alarm(0)
+		}
+		return check.AlarmLevel(v, w, cr)
+	}
+
+	if c.situation != powerMains {
+		return check.Alarm(0)
+	}
+
+	return check.AlarmLevel(v, w, cr)
+}
+
+// Is sensor generator side electrical sensor
+func isGenSensor(k string) bool {
+	return strings.HasPrefix(k, "Gen ")
+}
+
+// Report power situation as top-line status of result
+func (c *checkPowerGen) reportPower(check *checkResult) {
+	if c.situation == "" {
+		check.SetStatus(3, "Power: Na")
+		return
+	}
+
+	p := c.subParams
+	level := policyState(c.situation, p.powerPolicy, p.policy.policy.Power, defaultPowerPolicy)
+	check.SetStatus(level, "Power: "+powerNames[c.situation])
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestPowerSituation(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		modify func(i *godevman.GenInfo)
+		exp    string
+	}{
+		{"on mains", nil, func(i *godevman.GenInfo) {}, powerMains},
+		{"breakers off on mains", nil, func(i *godevman.GenInfo) { i.BreakerState = valStr("BrksOff") }, powerMains},
+		{"on generator", nil, func(i *godevman.GenInfo) {
+			i.BreakerState = valStr("IslOper")
+			i.MainsVoltL1 = sensor("V", 0, 0)
+		}, powerGenerator},
+		{"synchronizing", nil, func(i *godevman.GenInfo) { i.BreakerState = valStr("Synchro") }, powerTransfer},
+		{"engine starting on mains failure", nil, func(i *godevman.GenInfo) {
+			i.MainsVoltL2 = sensor("V", 0, 0)
+			i.EngineState = valStr("Cranking")
+		}, powerTransfer},
+		{"soft load on breakers off", nil, func(i *godevman.GenInfo) {
+			i.BreakerState = valStr("BrksOff")
+			i.EngineState = valStr("SoftLoad")
+			i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3 = sensor("V", 0, 0), sensor("V", 0, 0), sensor("V", 0, 0)
+			i.GenVoltL1, i.GenVoltL2, i.GenVoltL3 = sensor("V", 230, 0), sensor("V", 230, 0), sensor("V", 230, 0)
+			i.GenPower = sensor("kW", 7, 0)
+		}, powerTransfer},
+		{"blackout", nil, func(i *godevman.GenInfo) {
+			i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3 = sensor("V", 0, 0), sensor("V", 0, 0), sensor("V", 0, 0)
+			i.EngineState = valStr("NotReady")
+		}, powerBlackout},
+		{"low mains voltage", nil, func(i *godevman.GenInfo) { i.MainsVoltL3 = sensor("V", 180, 0) }, powerBlackout},
+		{"mains thresholds", []string{"-cmv", "170:260"}, func(i *godevman.GenInfo) { i.MainsVoltL3 = sensor("V", 180, 0) }, powerMains},
+		{"single phase ignores L2", []string{"-phases", "1"}, func(i *godevman.GenInfo) { i.MainsVoltL2 = sensor("V", 0, 0) }, powerMains},
+		{"no mains voltage on mains", nil, func(i *godevman.GenInfo) {
+			i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3 = godevman.SensorVal{}, godevman.SensorVal{}, godevman.SensorVal{}
+		}, powerMains},
+		{"no mains voltage breakers off", nil, func(i *godevman.GenInfo) {
+			i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3 = godevman.SensorVal{}, godevman.SensorVal{}, godevman.SensorVal{}
+			i.BreakerState = valStr("BrksOff")
+		}, powerBlackout},
+		{"unknown", nil, func(i *godevman.GenInfo) {
+			i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3 = godevman.SensorVal{}, godevman.SensorVal{}, godevman.SensorVal{}
+			i.BreakerState = godevman.ValString{}
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := testGenInfo()
+			tt.modify(&i)
+			if s := newTestPowerGen(t, tt.args...).powerSituation(i); s != tt.exp {
+				t.Errorf("expected %q, got %q", tt.exp, s)
+			}
+		})
+	}
+}
+
+// State lists must use exact godevman breaker and engine state values
+func TestGodevmanStates(t *testing.T) {
+	lists := map[string][]string{
+		powerGenerator: genBreakerStates,
+		powerTransfer:  transferBreakerStates,
+		powerMains:     mainsBreakerStates,
+	}
+	breaker := []struct {
+		state string
+		list  string
+	}{
+		{"Init", ""},
+		{"BrksOff", ""},
+		{"IslOper", powerGenerator},
+		{"MainsOper", powerMains},
+		{"ParalOper", powerMains},
+		{"RevSync", powerTransfer},
+		{"Synchro", powerTransfer},
+		{"MainsFlt", powerTransfer},
+		{"ValidFlt", powerTransfer},
+		{"MainsRet", powerTransfer},
+		{"MultIslOp", powerGenerator},
+		{"MultParOp", powerMains},
+		{"EmergMan", ""},
+	}
+
+	n := 0
+	for _, tt := range breaker {
+		for name, l := range lists {
+			if in := inList(l, tt.state); in != (name == tt.list) {
+				t.Errorf("breaker %s - expected %s list %v, got %v", tt.state, name, !in, in)
+			}
+		}
+		if tt.list != "" {
+			n++
+		}
+	}
+	if all := len(genBreakerStates) + len(transferBreakerStates) + len(mainsBreakerStates); all != n {
+		t.Errorf("breaker lists have %d values, expected %d godevman values", all, n)
+	}
+
+	engine := []struct {
+		state   string
+		running bool
+	}{
+		{"Init", false},
+		{"Ready", false},
+		{"NotReady", false},
+		{"Prestart", true},
+		{"Cranking", true},
+		{"Pause", false},
+		{"Starting", true},
+		{"Running", true},
+		{"Loaded", true},
+		{"SoftUnld", true},
+		{"Cooling", true},
+		{"Stop", false},
+		{"Shutdown", false},
+		{"Ventil", false},
+		{"EmergMan", false},
+		{"SoftLoad", true},
+		{"WaitStop", true},
+		{"SDVentil", false},
+	}
+
+	n = 0
+	for _, tt := range engine {
+		if r := inList(runningEngineStates, tt.state); r != tt.running {
+			t.Errorf("engine %s - expected running %v, got %v", tt.state, tt.running, r)
+		}
+		if tt.running {
+			n++
+		}
+	}
+	if len(runningEngineStates) != n {
+		t.Errorf("running engine states have %d values, expected %d godevman values", len(runningEngineStates), n)
+	}
+}
+
+// Returns generator info of generator supplying load during mains failure
+func testGenOnLoad() godevman.GenInfo {
+	i := testGenInfo()
+	i.BreakerState = valStr("IslOper")
+	i.EngineState = valStr("Loaded")
+	i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3 = sensor("V", 0, 0), sensor("V", 0, 0), sensor("V", 0, 0)
+	i.GenVoltL1, i.GenVoltL2, i.GenVoltL3 = sensor("V", 231, 0), sensor("V", 230, 0), sensor("V", 232, 0)
+	i.GenCurrentL1, i.GenCurrentL2, i.GenCurrentL3 = sensor("A", 12, 0), sensor("A", 11, 0), sensor("A", 12, 0)
+	i.GenPower = sensor("kW", 8, 0)
+	i.GenFreq = sensor("Hz", 500, 10)
+
+	return i
+}
+
+func TestPowerGenRunPower(t *testing.T) {
+	common := []string{"-t", "all", "-breaker", "IslOper=ok", "-engine", "Loaded=ok"}
+	tests := []struct {
+		name    string
+		args    []string
+		gen     func() godevman.GenInfo
+		state   int
+		summary string
+	}{
+		{"on mains", nil, testGenInfo, 0, "Power: on mains; Common: OK; Electrical: OK; Engine: OK"},
+		{"on mains gen alarms suppressed", nil, func() godevman.GenInfo {
+			i := testGenInfo()
+			i.GenCurrentL1 = sensor("A", 30, 0)
+			return i
+		}, 0, "Power: on mains; Common: OK; Electrical: OK; Engine: OK"},
+		{"on generator", common, testGenOnLoad, 1, "Power: on generator(w); Common: OK; Electrical: OK; Engine: OK"},
+		{"on generator policy", append([]string{"-power", "generator=ok"}, common...), testGenOnLoad, 0,
+			"Power: on generator; Common: OK; Electrical: OK; Engine: OK"},
+		{"on generator gen warning", common, func() godevman.GenInfo {
+			i := testGenOnLoad()
+			i.GenPower = sensor("kW", 14, 0)
+			return i
+		}, 1, "Power: on generator(w); Gen Power: 14kW(w); Common: OK; Engine: OK"},
+		{"on generator gen critical", common, func() godevman.GenInfo {
+			i := testGenOnLoad()
+			i.GenPower = sensor("kW", 16, 0)
+			return i
+		}, 2, "Power: on generator(w); Gen Power: 16kW(c); Common: OK; Engine: OK"},
+		{"on generator zero gen voltage", common, func() godevman.GenInfo {
+			i := testGenOnLoad()
+			i.GenVoltL2 = sensor("V", 0, 0)
+			return i
+		}, 2, "Power: on generator(w); Gen Voltage L2: 0V(c); Gen Voltage Unbalance: 100.0%(c); Common: OK; Engine: OK"},
+		{"blackout", []string{"-t", "all", "-engine", "NotReady=warning"}, func() godevman.GenInfo {
+			i := testGenInfo()
+			i.EngineState = valStr("NotReady")
+			i.MainsVoltL1, i.MainsVoltL2, i.MainsVoltL3 = sensor("V", 0, 0), sensor("V", 0, 0), sensor("V", 0, 0)
+			return i
+		}, 2, "Power: blackout(c); Engine: NotReady(w); Electrical: OK; Engine: OK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := tt.gen()
+			args := tt.args
+			if args == nil {
+				args = []string{"-t", "all"}
+			}
+			res := newTestPowerGen(t, args...).Run(context.Background(), checkParams{devices: &fakeDevice{Gen: &i}})
+			if res.Err() != nil {
+				t.Fatalf("unexpected error: %v", res.Err())
+			}
+			if res.RetVal() != tt.state {
+				t.Errorf("state - expected %d, got %d (%s)", tt.state, res.RetVal(), res.Summary())
+			}
+			if s := res.Summary(); s != tt.summary {
+				t.Errorf("summary - expected %q, got %q", tt.summary, s)
+			}
+		})
+	}
+
+	// Power situation is not evaluated without common info
+	i := testGenInfo()
+	res := newTestPowerGen(t, "-t", "electrical,engine").Run(context.Background(), checkParams{devices: &fakeDevice{Gen: &i}})
+	if strings.Contains(res.Summary(), "Power:") {
+		t.Errorf("unexpected power situation in %q", res.Summary())
+	}
+}
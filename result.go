@@ -0,0 +1,233 @@
+// Check result returned by checks
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Result message
+type resultMsg struct {
+	short string // message in summary line
+	long  string // message in long output
+}
+
+// Check result. Checks only collect data here. Printing and exit is done in main
+type checkResult struct {
+	err                  error       // error which prevented check from completing
+	name                 string      // first word in plugin output
+	perf                 []string    // performance data
+	sections             []string    // long output of merged sections
+	status               string      // top-line status. First message in summary
+	crit, warn, unkn, ok []resultMsg // messages by alarm level
+	state                int         // plugin exit state
+}
+
+// Initialize new check result. Initial state is UNKNOWN
+func newResult(name string) *checkResult {
+	return &checkResult{
+		name:  name,
+		state: 3,
+	}
+}
+
+// Get result state
+func (r *checkResult) RetVal() int {
+	return r.state
+}
+
+// Set result state
+func (r *checkResult) SetRetVal(v int) error {
+	if v < 0 || v > 3 {
+		return fmt.Errorf("not valid return value - %d", v)
+	}
+
+	r.state = v
+
+	return nil
+}
+
+// Set error which prevented check from completing. Returns result itself
+func (r *checkResult) Fail(err error) *checkResult {
+	r.err = err
+	return r
+}
+
+// Get error which prevented check from completing
+func (r *checkResult) Err() error {
+	return r.err
+}
+
+// Check value against thresholds
+// Returns alarm level. Raises result state if needed
+func (r *checkResult) AlarmLevel(v float64, warn, crit threshold) int {
+	return r.Alarm(thresholdLevel(v, warn, crit))
+}
+
+// Raise result state by alarm level. Initial UNKNOWN state is replaced by any level.
+// Returns alarm level
+func (r *checkResult) Alarm(level int) int {
+	if r.state == 3 || level > r.state {
+		r.state = level
+	}
+
+	return level
+}
+
+// Returns alarm level of value
+func thresholdLevel(v float64, warn, crit threshold) int {
+	switch {
+	case crit.alert(v):
+		return 2
+	case warn.alert(v):
+		return 1
+	}
+
+	return 0
+}
+
+// Add performance data item
+func (r *checkResult) AddPerf(p perfData) {
+	r.perf = append(r.perf, p.String())
+}
+
+// Raise result state to level if level is worse than current state. CRITICAL > WARNING > UNKNOWN > OK
+func (r *checkResult) Raise(level int) {
+	if worseState(level, r.state) {
+		r.state = level
+	}
+}
+
+// Add message with corresponding alarm level
+func (r *checkResult) AddMsg(level int, short, long string) {
+	m := resultMsg{
+		short: short,
+		long:  long,
+	}
+
+	switch level {
+	case 2:
+		r.crit = append(r.crit, m)
+	case 1:
+		r.warn = append(r.warn, m)
+	case 0:
+		r.ok = append(r.ok, m)
+	default:
+		r.unkn = append(r.unkn, m)
+	}
+}
+
+// Merge section result. Section problem messages and performance data are added to result.
+// Section without problems is summarized as "<section>: OK".
+// Full section summary is added to long output. Result state is the worst of states.
+func (r *checkResult) Merge(s *checkResult) {
+	first := len(r.sections) == 0
+	r.crit = append(r.crit, s.crit...)
+	r.warn = append(r.warn, s.warn...)
+	r.unkn = append(r.unkn, s.unkn...)
+	if s.crit == nil && s.warn == nil && s.unkn == nil {
+		r.ok = append(r.ok, resultMsg{short: s.name + ": OK"})
+	}
+	r.perf = append(r.perf, s.perf...)
+	r.sections = append(r.sections, fmt.Sprintf("%s: %s - %s", s.name, s.StateName(), s.Summary()))
+	if l := s.LongOutput(); l != "" {
+		r.sections = append(r.sections, l)
+	}
+
+	// First merged section defines initial state
+	if first || worseState(s.state, r.state) {
+		r.state = s.state
+	}
+}
+
+// Is state a worse than state b. CRITICAL > WARNING > UNKNOWN > OK
+func worseState(a, b int) bool {
+	rank := map[int]int{0: 0, 3: 1, 1: 2, 2: 3}
+	return rank[a] > rank[b]
+}
+
+// Returns state name
+func (r *checkResult) StateName() string {
+	switch r.state {
+	case 2:
+		return "CRITICAL"
+	case 1:
+		return "WARNING"
+	case 0:
+		return "OK"
+	}
+
+	return "UNKNOWN"
+}
+
+// Returns messages ordered by alarm level with corresponding suffixes
+func (r *checkResult) messages() (short, long []string) {
+	groups := []struct {
+		msgs       []resultMsg
+		sSfx, lSfx string
+	}{
+		{r.crit, "(c)", "(c)"},
+		{r.warn, "(w)", "(w)"},
+		{r.unkn, "(u)", "(u)"},
+		{r.ok, "", "(ok)"},
+	}
+
+	for _, g := range groups {
+		for _, m := range g.msgs {
+			short = append(short, m.short+g.sSfx)
+			if m.long != "" {
+				long = append(long, m.long+g.lSfx)
+			}
+		}
+	}
+
+	return short, long
+}
+
+// Set top-line status message with alarm level. Raises result state to level,
+// so it must be set after merging sections.
+func (r *checkResult) SetStatus(level int, msg string) {
+	sfx := map[int]string{2: "(c)", 1: "(w)", 3: "(u)"}
+	r.status = msg + sfx[level]
+	r.Raise(level)
+}
+
+// Returns summary part of plugin output
+func (r *checkResult) Summary() string {
+	s, _ := r.messages()
+	if r.status != "" {
+		s = append([]string{r.status}, s...)
+	}
+
+	return strings.Join(s, "; ")
+}
+
+// Returns long output part of plugin output
+func (r *checkResult) LongOutput() string {
+	_, l := r.messages()
+	return strings.Join(append(r.sections, l...), "\n")
+}
+
+// Returns performance data part of plugin output
+func (r *checkResult) PerfData() string {
+	return strings.Join(r.perf, " ")
+}
+
+// Returns plugin output. Error which prevented check from completing is reported as one line output
+func (r *checkResult) Output() string {
+	if r.err != nil {
+		return fmt.Sprintf("%s: %s - %v\n", r.name, r.StateName(), r.err)
+	}
+
+	perf := ""
+	if r.perf != nil {
+		perf = "|" + r.PerfData()
+	}
+
+	out := fmt.Sprintf("%s: %s - %s %s\n", r.name, r.StateName(), r.Summary(), perf)
+	if l := r.LongOutput(); l != "" {
+		out = fmt.Sprintf("%s\n%s", out, l)
+	}
+
+	return out
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestResultOutput(t *testing.T) {
+	r := newResult("GEN")
+	r.SetRetVal(0)
+	r.AddMsg(0, "Mode: Auto", "")
+	r.AddMsg(2, "Engine: Running", "engine details")
+	r.AddMsg(3, "Breaker: Na", "")
+	r.AddPerf(newPerf("Gen Power", 5).Thresholds(mustThreshold("13"), mustThreshold("15")).Min(0))
+	r.AlarmLevel(16, mustThreshold("13"), mustThreshold("15"))
+
+	exp := "GEN: CRITICAL - Engine: Running(c); Breaker: Na(u); Mode: Auto |'Gen Power'=5;13;15;0;\n\nengine details(c)"
+	if o := r.Output(); o != exp {
+		t.Errorf("output - expected %q, got %q", exp, o)
+	}
+}
+
+func TestResultAlarmLevel(t *testing.T) {
+	tests := []struct {
+		val        float64
+		warn, crit string
+		level      int
+	}{
+		{230, "215:245", "210:250", 0},
+		{212, "215:245", "210:250", 1},
+		{251, "215:245", "210:250", 2},
+		{15, "@10:20", "@12:18", 2},
+		{25, "24", "27", 1},
+		{49.4, "49.5:50.5", "48:52", 1},
+		{3, "~:5", "10:", 2},
+		{3, "", "", 0},
+	}
+
+	for _, tt := range tests {
+		r := newResult("TEST")
+		l := r.AlarmLevel(tt.val, mustThreshold(tt.warn), mustThreshold(tt.crit))
+		if l != tt.level || r.RetVal() != tt.level {
+			t.Errorf("%v w%s c%s - expected level %d, got %d (state %d)", tt.val, tt.warn, tt.crit, tt.level, l, r.RetVal())
+		}
+	}
+}
+
+func TestResultOutputError(t *testing.T) {
+	r := newResult("GEN")
+	r.Fail(fmt.Errorf("timeout after 10s while fetching generator info"))
+
+	exp := "GEN: UNKNOWN - timeout after 10s while fetching generator info\n"
+	if o := r.Output(); o != exp {
+		t.Errorf("expected %q, got %q", exp, o)
+	}
+}
+
+func TestResultMerge(t *testing.T) {
+	r := newResult("GEN")
+
+	common := newResult("Common")
+	common.SetRetVal(0)
+	common.AddMsg(0, "Mode: Auto", "")
+
+	engine := newResult("Engine")
+	engine.SetRetVal(0)
+	engine.AddMsg(0, "Battery Voltage: 13.6V", "")
+	engine.AddMsg(3, "Number of Starts: Na", "")
+	engine.AddPerf(newPerf("Battery Voltage", 136).Min(0))
+
+	r.Merge(common)
+	r.Merge(engine)
+
+	exp := "GEN: OK - Number of Starts: Na(u); Common: OK |'Battery Voltage'=136;;;0;\n\n" +
+		"Common: OK - Mode: Auto\nEngine: OK - Number of Starts: Na(u); Battery Voltage: 13.6V"
+	if o := r.Output(); o != exp {
+		t.Errorf("output - expected %q, got %q", exp, o)
+	}
+
+	crit := newResult("Electrical")
+	crit.SetRetVal(2)
+	r.Merge(crit)
+	if r.RetVal() != 2 {
+		t.Errorf("state - expected 2, got %d", r.RetVal())
+	}
+}
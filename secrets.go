@@ -0,0 +1,132 @@
+// SNMP secrets from environment, file or stdin
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Flags which values can be provided outside of command line.
+// Env variable names are CHECK_GODEVMAN_<flag name>, keys in secrets file are flag names.
+var secretFlags = []string{"u", "A", "X"}
+
+// Prefix of env variables which contain secrets
+const secretEnvPrefix = "CHECK_GODEVMAN_"
+
+// Read secrets from file or from stdin if path is "-".
+// File must not be accessible by others.
+func readSecrets(path string, stdin io.Reader) (map[string]string, error) {
+	if path == "-" {
+		return parseSecrets(stdin)
+	}
+
+	if err := checkPrivate(path, "secrets file"); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("secrets file: %v", err)
+	}
+	defer f.Close()
+
+	return parseSecrets(f)
+}
+
+// Returns error if file containing secrets is accessible by others.
+// Kind is file description used in error messages.
+func checkPrivate(path, kind string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s: %v", kind, err)
+	}
+	if fi.Mode().Perm()&0o007 != 0 {
+		return fmt.Errorf("%s %s is accessible by others (mode %04o), refusing to use it",
+			kind, path, fi.Mode().Perm())
+	}
+
+	return nil
+}
+
+// Parse "<flag name>=<value>" lines. Empty lines and lines starting with # are ignored
+func parseSecrets(r io.Reader) (map[string]string, error) {
+	out := map[string]string{}
+	sc := bufio.NewScanner(r)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		k = strings.TrimSpace(k)
+		if !ok || !isSecretFlag(k) {
+			return nil, fmt.Errorf("secrets line %d: expected <%s>=<value>", n, strings.Join(secretFlags, "|"))
+		}
+		out[k] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read secrets: %v", err)
+	}
+
+	return out, nil
+}
+
+// Returns secrets from env variables
+func envSecrets() map[string]string {
+	out := map[string]string{}
+	for _, k := range secretFlags {
+		if v, ok := os.LookupEnv(secretEnvPrefix + k); ok {
+			out[k] = v
+		}
+	}
+
+	return out
+}
+
+func isSecretFlag(name string) bool {
+	for _, k := range secretFlags {
+		if k == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply secrets to check params.
+// Values of flags which are explicitly set on command line are not overridden.
+func applySecrets(params *checkParams, secrets map[string]string, setFlags map[string]bool) {
+	c := &params.devParams.SnmpCred
+	for k, v := range secrets {
+		if setFlags[k] {
+			continue
+		}
+
+		switch k {
+		case "u":
+			c.User = v
+		case "A":
+			c.Pass = v
+		case "X":
+			c.PrivPass = v
+		}
+	}
+}
+
+// Returns params with SNMP secrets replaced, so params can be logged
+func (p checkParams) redacted() checkParams {
+	c := &p.devParams.SnmpCred
+	for _, s := range []*string{&c.User, &c.Pass, &c.PrivPass} {
+		if *s != "" {
+			*s = "<redacted>"
+		}
+	}
+
+	return p
+}
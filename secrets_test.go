@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestReadSecrets(t *testing.T) {
+	dir := t.TempDir()
+	data := "# SNMPv3 secrets\nu=monitor\nA=auth=secret\n\nX=privsecret\n"
+
+	good := filepath.Join(dir, "good")
+	if err := os.WriteFile(good, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	open := filepath.Join(dir, "open")
+	if err := os.WriteFile(open, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(dir, "bad")
+	if err := os.WriteFile(bad, []byte("pass=secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]string{"u": "monitor", "A": "auth=secret", "X": "privsecret"}
+	tests := []struct {
+		name  string
+		path  string
+		stdin string
+		err   bool
+	}{
+		{"file", good, "", false},
+		{"stdin", "-", data, false},
+		{"world readable", open, "", true},
+		{"unknown key", bad, "", true},
+		{"missing", filepath.Join(dir, "none"), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := readSecrets(tt.path, strings.NewReader(tt.stdin))
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for k, v := range exp {
+				if s[k] != v {
+					t.Errorf("%s - expected %q, got %q", k, v, s[k])
+				}
+			}
+		})
+	}
+}
+
+func TestApplySecrets(t *testing.T) {
+	t.Setenv(secretEnvPrefix+"A", "envauth")
+	p := checkParams{devParams: godevman.Dparams{SnmpCred: godevman.SnmpCred{User: "cli", Pass: "default"}}}
+
+	applySecrets(&p, map[string]string{"u": "fileuser", "X": "filepriv"}, map[string]bool{"u": true})
+	applySecrets(&p, envSecrets(), map[string]bool{"u": true})
+
+	exp := godevman.SnmpCred{User: "cli", Pass: "envauth", PrivPass: "filepriv"}
+	if p.devParams.SnmpCred != exp {
+		t.Errorf("expected %+v, got %+v", exp, p.devParams.SnmpCred)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	p := checkParams{devParams: godevman.Dparams{Ip: "192.0.2.1",
+		SnmpCred: godevman.SnmpCred{User: "monitor", Prot: "SHA", Pass: "authsecret", PrivPass: "privsecret"}}}
+
+	r := p.redacted()
+	exp := godevman.SnmpCred{User: "<redacted>", Prot: "SHA", Pass: "<redacted>", PrivPass: "<redacted>"}
+	if r.devParams.SnmpCred != exp || r.devParams.Ip != "192.0.2.1" {
+		t.Errorf("expected %+v, got %+v", exp, r.devParams)
+	}
+	if p.devParams.SnmpCred.Pass != "authsecret" {
+		t.Errorf("original params changed: %+v", p.devParams.SnmpCred)
+	}
+}
@@ -0,0 +1,179 @@
+// Unexpected engine start detection
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+// Week day names used in exercise windows
+var weekDays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// Exercise window. Engine starts within window are expected
+type exerciseWindow struct {
+	days       [7]bool       // by time.Weekday
+	start, end time.Duration // since midnight. End before start means next day
+}
+
+// Exercise windows. Implements flag.Value.
+// Format is <days> <HH:MM>-<HH:MM>[;...] where days is comma separated list of Mon..Sun or * for every day,
+// fe. "Mon,Thu 10:00-10:30;Sat 23:30-00:30". Repeated flags are merged.
+type exerciseWindows []exerciseWindow
+
+func (ew *exerciseWindows) Set(s string) error {
+	for _, w := range strings.Split(s, ";") {
+		ex, err := parseExerciseWindow(strings.TrimSpace(w))
+		if err != nil {
+			return fmt.Errorf("not valid exercise window - %s: %v", w, err)
+		}
+		*ew = append(*ew, ex)
+	}
+
+	return nil
+}
+
+func parseExerciseWindow(s string) (exerciseWindow, error) {
+	var ex exerciseWindow
+	days, span, ok := strings.Cut(s, " ")
+	if !ok {
+		return ex, fmt.Errorf("expected <days> <HH:MM>-<HH:MM>")
+	}
+
+	for _, d := range strings.Split(days, ",") {
+		if d == "*" {
+			ex.days = [7]bool{true, true, true, true, true, true, true}
+			continue
+		}
+		n := -1
+		for i, wd := range weekDays {
+			if strings.EqualFold(wd, d) {
+				n = i
+			}
+		}
+		if n < 0 {
+			return ex, fmt.Errorf("unknown day %s", d)
+		}
+		ex.days[n] = true
+	}
+
+	start, end, ok := strings.Cut(strings.TrimSpace(span), "-")
+	if !ok {
+		return ex, fmt.Errorf("expected <HH:MM>-<HH:MM>")
+	}
+
+	var err error
+	if ex.start, err = parseClock(start); err != nil {
+		return ex, err
+	}
+	if ex.end, err = parseClock(end); err != nil {
+		return ex, err
+	}
+
+	return ex, nil
+}
+
+// Returns time since midnight of HH:MM
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("not valid time %s", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (ew *exerciseWindows) String() string {
+	if ew == nil {
+		return ""
+	}
+
+	out := make([]string, 0, len(*ew))
+	for _, ex := range *ew {
+		var days []string
+		for i, d := range ex.days {
+			if d {
+				days = append(days, weekDays[i])
+			}
+		}
+		if len(days) == 7 {
+			days = []string{"*"}
+		}
+
+		clock := func(d time.Duration) string {
+			return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+		}
+		out = append(out, fmt.Sprintf("%s %s-%s", strings.Join(days, ","), clock(ex.start), clock(ex.end)))
+	}
+
+	return strings.Join(out, ";")
+}
+
+// Does period from-to overlap with some exercise window
+func (ew exerciseWindows) overlaps(from, to time.Time) bool {
+	// Window starting on previous day can reach into period
+	day := time.Date(from.Year(), from.Month(), from.Day()-1, 0, 0, 0, 0, from.Location())
+	for ; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, ex := range ew {
+			if !ex.days[day.Weekday()] {
+				continue
+			}
+
+			start, end := day.Add(ex.start), day.Add(ex.end)
+			if ex.end < ex.start {
+				end = end.Add(24 * time.Hour)
+			}
+			if !start.After(to) && !end.Before(from) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Is unexpected start detection enabled
+func (c *checkPowerGen) startsSet() bool {
+	return c.subParams.detectStarts || len(c.subParams.exercise) > 0
+}
+
+// Detect engine starts outside exercise windows by change of number of starts since previous check.
+// Decreasing number of starts is handled as counter reset. Unexpected starts alarm is held
+// for event hold period. Starts during hold period are added to held alarm.
+func (c *checkPowerGen) unexpectedStarts(check *checkResult, i godevman.GenInfo) {
+	if !i.NumStarts.IsSet {
+		return
+	}
+
+	t := now()
+	st := &c.state
+	cur, prev, since := i.NumStarts.Value, st.NumStarts, st.StartsTime
+	st.NumStarts, st.StartsTime = cur, t
+
+	e, held := c.activeEvent("starts")
+	if !since.IsZero() && cur >= prev {
+		n := cur - prev
+		switch {
+		case n == 0:
+			check.AddPerf(newPerf("Unexpected Starts", 0).Min(0))
+		case c.subParams.exercise.overlaps(since, t):
+			check.AddMsg(0, fmt.Sprintf("Exercise Starts: %d", n), "")
+			check.AddPerf(newPerf("Unexpected Starts", 0).Min(0))
+		default:
+			if !held {
+				e = heldEvent{Level: 1, Since: since}
+			}
+			e.Value += float64(n)
+			e, held = c.holdEvent("starts", e), true
+			check.AddPerf(newPerf("Unexpected Starts", float64(n)).Min(0))
+		}
+	}
+
+	if held {
+		check.Alarm(e.Level)
+		check.AddMsg(e.Level, fmt.Sprintf("Unexpected Starts: %.0f", e.Value),
+			fmt.Sprintf("%.0f starts outside exercise window since %s", e.Value, e.Since.Format("2006-01-02 15:04")))
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aretaja/godevman"
+)
+
+func TestExerciseWindows(t *testing.T) {
+	tests := []struct {
+		val string
+		str string
+		err bool
+	}{
+		{"Mon 10:00-10:30", "Mon 10:00-10:30", false},
+		{"mon,THU 9:05-10:00", "Mon,Thu 09:05-10:00", false},
+		{"* 23:30-00:30;Sat 12:00-13:00", "* 23:30-00:30;Sat 12:00-13:00", false},
+		{"Mon", "", true},
+		{"Mon 10:00", "", true},
+		{"Xyz 10:00-11:00", "", true},
+		{"Mon 25:00-26:00", "", true},
+	}
+
+	for _, tt := range tests {
+		var ew exerciseWindows
+		err := ew.Set(tt.val)
+		if (err != nil) != tt.err {
+			t.Errorf("%q - expected error %v, got %v", tt.val, tt.err, err)
+			continue
+		}
+		if !tt.err && ew.String() != tt.str {
+			t.Errorf("%q - expected %q, got %q", tt.val, tt.str, ew.String())
+		}
+	}
+}
+
+func TestExerciseWindowsOverlaps(t *testing.T) {
+	var ew exerciseWindows
+	if err := ew.Set("Mon 10:00-10:30;Sat 23:30-00:30"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2023-05-01 is Monday
+	at := func(day, h, m int) time.Time { return time.Date(2023, 5, day, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		from, to time.Time
+		exp      bool
+	}{
+		{"inside", at(1, 10, 5), at(1, 10, 10), true},
+		{"covers window", at(1, 9, 55), at(1, 10, 40), true},
+		{"ends in window", at(1, 9, 55), at(1, 10, 0), true},
+		{"before", at(1, 9, 0), at(1, 9, 55), false},
+		{"after", at(1, 10, 31), at(1, 10, 36), false},
+		{"other day", at(2, 10, 5), at(2, 10, 10), false},
+		{"after midnight", at(7, 0, 10), at(7, 0, 15), true},
+		{"before midnight", at(6, 23, 40), at(6, 23, 45), true},
+		{"sunday night", at(7, 23, 40), at(7, 23, 45), false},
+		{"long period", at(1, 11, 0), at(8, 9, 0), true},
+	}
+
+	for _, tt := range tests {
+		if o := ew.overlaps(tt.from, tt.to); o != tt.exp {
+			t.Errorf("%s - expected %v, got %v", tt.name, tt.exp, o)
+		}
+	}
+}
+
+func TestPowerGenUnexpectedStarts(t *testing.T) {
+	dir := t.TempDir()
+	runs := []struct {
+		time   time.Time
+		starts uint64
+		state  int
+		msg    string
+		perf   string
+	}{
+		{time.Date(2023, 5, 1, 9, 50, 0, 0, time.Local), 16, 0, "", ""},
+		{time.Date(2023, 5, 1, 9, 55, 0, 0, time.Local), 16, 0, "", "'Unexpected Starts'=0;;;0;"},
+		{time.Date(2023, 5, 1, 10, 35, 0, 0, time.Local), 17, 0, "Exercise Starts: 1", "'Unexpected Starts'=0;;;0;"},
+		{time.Date(2023, 5, 1, 14, 0, 0, 0, time.Local), 19, 1, "Unexpected Starts: 2(w)", "'Unexpected Starts'=2;;;0;"},
+		{time.Date(2023, 5, 1, 14, 5, 0, 0, time.Local), 19, 1, "Unexpected Starts: 2(w)", "'Unexpected Starts'=0;;;0;"},
+		{time.Date(2023, 5, 1, 14, 30, 0, 0, time.Local), 20, 1, "Unexpected Starts: 3(w)", "'Unexpected Starts'=1;;;0;"},
+		{time.Date(2023, 5, 1, 15, 35, 0, 0, time.Local), 20, 0, "", "'Unexpected Starts'=0;;;0;"},
+		{time.Date(2023, 5, 1, 15, 40, 0, 0, time.Local), 2, 0, "", ""},
+	}
+
+	for _, r := range runs {
+		i := testGenInfo()
+		i.NumStarts = godevman.ValU64{Value: r.starts, IsSet: true}
+		res := runStateful(t, dir, r.time, i, "-t", "engine", "-exercise", "Mon 10:00-10:30")
+
+		if res.RetVal() != r.state {
+			t.Errorf("%s - expected state %d, got %d (%s)", r.time, r.state, res.RetVal(), res.Summary())
+		}
+		if r.msg != "" && !strings.Contains(res.Summary(), r.msg) {
+			t.Errorf("%s - expected %q in %q", r.time, r.msg, res.Summary())
+		}
+		if r.msg == "" && strings.Contains(res.Summary(), "Starts:") && !strings.Contains(res.Summary(), "Number of Starts") {
+			t.Errorf("%s - unexpected starts message in %q", r.time, res.Summary())
+		}
+		if r.perf == "" && strings.Contains(res.PerfData(), "Unexpected Starts") {
+			t.Errorf("%s - unexpected perfdata in %q", r.time, res.PerfData())
+		}
+		if !strings.Contains(res.PerfData(), r.perf) {
+			t.Errorf("%s - expected %q in %q", r.time, r.perf, res.PerfData())
+		}
+		if r.state == 1 && !strings.Contains(res.LongOutput(), " starts outside exercise window since 2023-05-01 10:35(w)") {
+			t.Errorf("%s - unexpected long output %q", r.time, res.LongOutput())
+		}
+	}
+}
+
+func TestPowerGenUnexpectedStartsNoHold(t *testing.T) {
+	dir := t.TempDir()
+	runs := []struct {
+		time   time.Time
+		starts uint64
+		state  int
+		msg    string
+	}{
+		{time.Date(2023, 5, 1, 13, 55, 0, 0, time.Local), 16, 0, ""},
+		{time.Date(2023, 5, 1, 14, 0, 0, 0, time.Local), 18, 1, "Unexpected Starts: 2(w)"},
+		{time.Date(2023, 5, 1, 14, 5, 0, 0, time.Local), 18, 0, ""},
+		{time.Date(2023, 5, 1, 14, 10, 0, 0, time.Local), 19, 1, "Unexpected Starts: 1(w)"},
+	}
+
+	for _, r := range runs {
+		i := testGenInfo()
+		i.NumStarts = godevman.ValU64{Value: r.starts, IsSet: true}
+		setMovingNow(t, r.time)
+		p := checkParams{devices: &fakeDevice{Gen: &i}, stateDir: dir, devParams: godevman.Dparams{Ip: "192.0.2.1"}}
+		res := newTestPowerGen(t, "-t", "engine", "-detect-starts", "-event-hold", "0").Run(context.Background(), p)
+
+		if res.RetVal() != r.state {
+			t.Errorf("%s - expected state %d, got %d (%s)", r.time, r.state, res.RetVal(), res.Summary())
+		}
+		if r.msg != "" && !strings.Contains(res.Summary(), r.msg) {
+			t.Errorf("%s - expected %q in %q", r.time, r.msg, res.Summary())
+		}
+		if r.msg == "" && strings.Contains(res.Summary(), "Unexpected Starts:") {
+			t.Errorf("%s - unexpected starts message in %q", r.time, res.Summary())
+		}
+	}
+}
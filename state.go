@@ -0,0 +1,94 @@
+// Persistent check state between plugin runs
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Default state directory. Can be overridden by -state-dir flag
+const defaultStateDir = "/var/lib/check-godevman-multi"
+
+// Current time. Replaced in tests
+var now = time.Now
+
+// State file of one host and check
+type stateFile struct {
+	path string
+}
+
+// Initialize state file of host and check in directory
+func newStateFile(dir, host, check string) stateFile {
+	return stateFile{path: filepath.Join(dir, stateName(host)+"_"+check+".json")}
+}
+
+// Returns host name usable in file name. Empty host is "default"
+func stateName(host string) string {
+	if host == "" {
+		return "default"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, host)
+}
+
+// Load state into v. Missing state file leaves v unchanged
+func (f stateFile) Load(v any) error {
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read state: %v", err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("parse state %s: %v", f.path, err)
+	}
+
+	return nil
+}
+
+// Save state. Missing state directory is created.
+// File is replaced atomically, so concurrent runs never see partial state
+func (f stateFile) Save(v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("save state: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save state: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save state: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save state: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("save state: %v", err)
+	}
+
+	return nil
+}
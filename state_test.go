@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateName(t *testing.T) {
+	tests := map[string]string{
+		"":                  "default",
+		"gen1.example.com":  "gen1.example.com",
+		"192.0.2.1":         "192.0.2.1",
+		"[2001:db8::1]:161": "_2001_db8__1__161",
+		"../etc/passwd":     ".._etc_passwd",
+	}
+
+	for host, exp := range tests {
+		if n := stateName(host); n != exp {
+			t.Errorf("%q - expected %q, got %q", host, exp, n)
+		}
+	}
+}
+
+func TestStateFile(t *testing.T) {
+	dir := t.TempDir()
+	f := newStateFile(dir, "192.0.2.1", "power_gen")
+	if exp := filepath.Join(dir, "192.0.2.1_power_gen.json"); f.path != exp {
+		t.Errorf("path - expected %q, got %q", exp, f.path)
+	}
+
+	// Missing state
+	var st genState
+	if err := f.Load(&st); err != nil || !st.Updated.IsZero() {
+		t.Fatalf("missing state - expected empty state, got %v %v", st, err)
+	}
+
+	ts := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	in := genState{Updated: ts, RunHours: 61.7, Fuel: []fuelSample{{Time: ts, Level: 73}}}
+	if err := f.Save(in); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	var out genState
+	if err := f.Load(&out); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !out.Updated.Equal(ts) || out.RunHours != 61.7 || len(out.Fuel) != 1 || out.Fuel[0].Level != 73 {
+		t.Errorf("expected %+v, got %+v", in, out)
+	}
+
+	if fi, err := os.Stat(f.path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected state file with 0600 mode, got %v %v", fi, err)
+	}
+
+	// Corrupt state
+	if err := os.WriteFile(f.path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Load(&out); err == nil {
+		t.Error("corrupt state - expected error, got nil")
+	}
+
+	// Missing directory is created
+	if err := newStateFile(filepath.Join(dir, "missing"), "h", "c").Save(in); err != nil {
+		t.Errorf("missing dir - unexpected error: %v", err)
+	}
+
+	// Directory can not be created
+	if err := newStateFile(filepath.Join(f.path, "sub"), "h", "c").Save(in); err == nil {
+		t.Error("bad dir - expected error, got nil")
+	}
+}
+
+func TestPowerGenStateFile(t *testing.T) {
+	tests := []struct {
+		args []string
+		exp  string
+	}{
+		{[]string{"-t", "engine"}, "192.0.2.1_power_gen_engine.json"},
+		{[]string{"-t", "engine,common"}, "192.0.2.1_power_gen_common-engine.json"},
+		{[]string{"-t", "all"}, "192.0.2.1_power_gen_common-electrical-engine.json"},
+	}
+
+	for _, tt := range tests {
+		c := newTestPowerGen(t, tt.args...)
+		c.stateDir, c.devParams.Ip = "/state", "192.0.2.1"
+		if p := c.stateFile().path; p != filepath.Join("/state", tt.exp) {
+			t.Errorf("%v - expected %q, got %q", tt.args, tt.exp, p)
+		}
+	}
+}
+
+func TestPowerGenStateSaveFailed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		fuel  uint64
+		state int
+	}{
+		{"ok", 73, 3},
+		{"critical", 5, 2},
+	}
+
+	for _, tt := range tests {
+		i := testGenInfo()
+		i.FuelLevel = sensor("%", tt.fuel, 0)
+		res := runStateful(t, filepath.Join(file, "state"), time.Now(), i, "-t", "engine", "-tank", "500")
+
+		if res.RetVal() != tt.state {
+			t.Errorf("%s - expected state %d, got %d", tt.name, tt.state, res.RetVal())
+		}
+		if s := res.Summary(); !strings.Contains(s, "State: save failed(u)") || !strings.Contains(s, "Battery Voltage: 13.6V") {
+			t.Errorf("%s - unexpected summary %q", tt.name, s)
+		}
+	}
+}
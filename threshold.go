@@ -0,0 +1,227 @@
+// Nagios plugin range thresholds
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aretaja/godevman"
+)
+
+// Threshold range. Implements flag.Value, so malformed threshold fails on argument parsing.
+//
+// Syntax: [@][start:]end
+//
+//	10     - alert if value < 0 or > 10
+//	10:    - alert if value < 10
+//	~:10   - alert if value > 10
+//	10:20  - alert if value < 10 or > 20
+//	@10:20 - alert if value >= 10 and <= 20
+//
+// Empty threshold never alerts.
+type threshold struct {
+	start, end float64
+	inside     bool
+	set        bool
+}
+
+// Parse threshold string
+func parseThreshold(s string) (threshold, error) {
+	t := threshold{start: 0, end: math.Inf(1)}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return t, nil
+	}
+	t.set = true
+
+	r := s
+	if strings.HasPrefix(r, "@") {
+		t.inside = true
+		r = r[1:]
+	}
+
+	start, end, hasStart := strings.Cut(r, ":")
+	if !hasStart {
+		start, end = "", start
+	}
+
+	if hasStart {
+		switch start {
+		case "~":
+			t.start = math.Inf(-1)
+		case "":
+			return t, fmt.Errorf("not valid threshold - %s: missing range start", s)
+		default:
+			v, err := parseNum(start)
+			if err != nil {
+				return t, fmt.Errorf("not valid threshold - %s: %v", s, err)
+			}
+			t.start = v
+		}
+	}
+
+	if end != "" {
+		v, err := parseNum(end)
+		if err != nil {
+			return t, fmt.Errorf("not valid threshold - %s: %v", s, err)
+		}
+		t.end = v
+	} else if !hasStart {
+		return t, fmt.Errorf("not valid threshold - %s: missing range end", s)
+	}
+
+	if t.start > t.end {
+		return t, fmt.Errorf("not valid threshold - %s: start is greater than end", s)
+	}
+
+	return t, nil
+}
+
+func parseNum(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%s is not a number", s)
+	}
+
+	return v, nil
+}
+
+// Parse threshold which is known to be valid
+func mustThreshold(s string) threshold {
+	t, err := parseThreshold(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// Does value raise alert
+func (t threshold) alert(v float64) bool {
+	if !t.set {
+		return false
+	}
+
+	out := v < t.start || v > t.end
+	if t.inside {
+		return !out
+	}
+
+	return out
+}
+
+// Returns threshold in range syntax
+func (t threshold) String() string {
+	if !t.set {
+		return ""
+	}
+
+	out := ""
+	if t.inside {
+		out = "@"
+	}
+
+	switch {
+	case math.IsInf(t.start, -1):
+		out += "~:"
+	case t.start != 0 || math.IsInf(t.end, 1):
+		out += formatNum(t.start) + ":"
+	}
+
+	if !math.IsInf(t.end, 1) {
+		out += formatNum(t.end)
+	}
+
+	return out
+}
+
+func formatNum(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Set threshold from flag value
+func (t *threshold) Set(s string) error {
+	v, err := parseThreshold(s)
+	if err != nil {
+		return err
+	}
+	*t = v
+
+	return nil
+}
+
+// Define threshold flag with default value
+func thresholdVar(fs *flag.FlagSet, t *threshold, name, def, usage string) {
+	*t = mustThreshold(def)
+	fs.Var(t, name, usage)
+}
+
+// Returns sensor divisor. Zero divisor means no scaling
+func sensorDivisor(s godevman.SensorVal) float64 {
+	if s.Divisor == 0 {
+		return 1
+	}
+
+	return float64(s.Divisor)
+}
+
+// Returns sensor value in engineering units
+func sensorValue(s godevman.SensorVal) float64 {
+	return float64(s.Value) / sensorDivisor(s)
+}
+
+// Per phase threshold. Implements flag.Value.
+// One range applies to all phases, three comma separated ranges are for L1, L2 and L3.
+// Empty range means no threshold for that phase.
+type phaseThreshold struct {
+	ph  [3]threshold
+	set bool
+}
+
+// Set per phase threshold from flag value
+func (t *phaseThreshold) Set(s string) error {
+	parts := strings.Split(s, ",")
+	switch len(parts) {
+	case 1:
+		parts = []string{s, s, s}
+	case 3:
+	default:
+		return fmt.Errorf("not valid per phase threshold - %s: expected 1 or 3 comma separated ranges", s)
+	}
+
+	var ph [3]threshold
+	for i, p := range parts {
+		v, err := parseThreshold(p)
+		if err != nil {
+			return err
+		}
+		ph[i] = v
+	}
+	t.ph, t.set = ph, true
+
+	return nil
+}
+
+// Returns per phase threshold in range syntax
+func (t phaseThreshold) String() string {
+	if !t.set {
+		return ""
+	}
+	if t.ph[0] == t.ph[1] && t.ph[1] == t.ph[2] {
+		return t.ph[0].String()
+	}
+
+	return t.ph[0].String() + "," + t.ph[1].String() + "," + t.ph[2].String()
+}
+
+// Returns threshold of phase 1-3
+func (t phaseThreshold) phase(n int) threshold {
+	if n < 1 || n > 3 {
+		return threshold{}
+	}
+
+	return t.ph[n-1]
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		th     string
+		str    string
+		alert  []float64
+		silent []float64
+	}{
+		{"10", "10", []float64{-1, 10.5}, []float64{0, 5, 10}},
+		{"10:", "10:", []float64{9.9, -5}, []float64{10, 1e9}},
+		{"~:10", "~:10", []float64{10.1}, []float64{-1e9, 10}},
+		{"10:20", "10:20", []float64{9, 21}, []float64{10, 15, 20}},
+		{"@10:20", "@10:20", []float64{10, 15, 20}, []float64{9.9, 20.1}},
+		{"@~:0", "@~:0", []float64{-3, 0}, []float64{0.1}},
+		{"49.5:50.5", "49.5:50.5", []float64{49.4, 50.6}, []float64{49.5, 50}},
+		{"-1.5:1.5", "-1.5:1.5", []float64{-2}, []float64{0}},
+		{"", "", nil, []float64{-1e9, 0, 1e9}},
+	}
+
+	for _, tt := range tests {
+		th, err := parseThreshold(tt.th)
+		if err != nil {
+			t.Errorf("%q - unexpected error: %v", tt.th, err)
+			continue
+		}
+		if s := th.String(); s != tt.str {
+			t.Errorf("%q - expected string %q, got %q", tt.th, tt.str, s)
+		}
+		for _, v := range tt.alert {
+			if !th.alert(v) {
+				t.Errorf("%q - expected alert for %v", tt.th, v)
+			}
+		}
+		for _, v := range tt.silent {
+			if th.alert(v) {
+				t.Errorf("%q - unexpected alert for %v", tt.th, v)
+			}
+		}
+	}
+}
+
+func TestParseThresholdErrors(t *testing.T) {
+	for _, th := range []string{"abc", "20:10", ":5", "@", "1.2.3", "10:x", "~", "NaN", "Inf"} {
+		if _, err := parseThreshold(th); err == nil {
+			t.Errorf("%q - expected error, got nil", th)
+		}
+	}
+}
+
+func TestPhaseThreshold(t *testing.T) {
+	tests := []struct {
+		th  string
+		str string
+		ph  [3]string
+		err bool
+	}{
+		{"215:245", "215:245", [3]string{"215:245", "215:245", "215:245"}, false},
+		{"215:245,,220:240", "215:245,,220:240", [3]string{"215:245", "", "220:240"}, false},
+		{"10,10,10", "10", [3]string{"10", "10", "10"}, false},
+		{"215:245,220:240", "", [3]string{}, true},
+		{"215:245,x,220:240", "", [3]string{}, true},
+	}
+
+	for _, tt := range tests {
+		var pt phaseThreshold
+		err := pt.Set(tt.th)
+		if (err != nil) != tt.err {
+			t.Errorf("%q - expected error %v, got %v", tt.th, tt.err, err)
+			continue
+		}
+		if tt.err {
+			continue
+		}
+		if s := pt.String(); s != tt.str {
+			t.Errorf("%q - expected string %q, got %q", tt.th, tt.str, s)
+		}
+		for i, exp := range tt.ph {
+			if s := pt.phase(i + 1).String(); s != exp {
+				t.Errorf("%q - expected L%d %q, got %q", tt.th, i+1, exp, s)
+			}
+		}
+	}
+}